@@ -24,6 +24,10 @@ from flask import Flask, g, jsonify, request
 # ---------------------------------------------------------------------------
 app = Flask(__name__)
 
+# Cart payloads are tiny; Flask answers 413 above this
+MAX_BODY_BYTES = int(os.environ.get("MAX_BODY_BYTES", str(64 * 1024)))
+app.config["MAX_CONTENT_LENGTH"] = MAX_BODY_BYTES
+
 
 # ---------------------------------------------------------------------------
 # Structured JSON logging to stdout
@@ -107,6 +111,7 @@ def after_request(response):
         "path": request.path,
         "source_ip": request.remote_addr,
         "user_agent": request.headers.get("User-Agent", ""),
+        "request_id": request.headers.get("X-Request-ID", ""),
         "response_code": response.status_code,
         "duration_ms": duration_ms,
     }
@@ -183,11 +188,49 @@ CART_SELECT_SQL = """
 # ---------------------------------------------------------------------------
 # Routes
 # ---------------------------------------------------------------------------
+@app.errorhandler(413)
+def request_too_large(_error):
+    return (
+        jsonify({"error": "Request body too large", "max_bytes": MAX_BODY_BYTES}),
+        413,
+    )
+
+
 @app.route("/health")
 def health():
     return jsonify({"status": "healthy", "service": "cart-service"})
 
 
+@app.route("/ready")
+def ready():
+    """Readiness: 503 unless the database answers. /health stays a cheap liveness check."""
+    try:
+        cur = get_db().cursor()
+        cur.execute("SELECT 1")
+        cur.close()
+        checks = {"postgres": {"ok": True}}
+    except Exception as e:
+        # Don't hand a broken connection back to the pool
+        db = g.pop("db", None)
+        if db is not None:
+            try:
+                get_db_pool().putconn(db, close=True)
+            except Exception:
+                pass
+        checks = {"postgres": {"ok": False, "error": str(e).strip()[:200]}}
+    ok = checks["postgres"]["ok"]
+    return (
+        jsonify(
+            {
+                "status": "ready" if ok else "not_ready",
+                "service": "cart-service",
+                "checks": checks,
+            }
+        ),
+        200 if ok else 503,
+    )
+
+
 @app.route("/api/cart/add", methods=["POST"])
 def add_to_cart():
     """Add an item to the session cart. Returns the full updated cart."""
@@ -18,30 +18,99 @@ const path = require('path');
 // Configuration (env-driven for Kubernetes)
 // ---------------------------------------------------------------------------
 const PORT = parseInt(process.env.PORT || '3000', 10);
+// Grace period for in-flight requests on SIGTERM
+const SHUTDOWN_TIMEOUT_MS = parseInt(process.env.SHUTDOWN_TIMEOUT_MS || '10000', 10);
+// Drop clients slower than this to send a request, or just its headers
+const REQUEST_TIMEOUT_MS = parseInt(process.env.REQUEST_TIMEOUT_MS || '30000', 10);
+const HEADERS_TIMEOUT_MS = parseInt(process.env.HEADERS_TIMEOUT_MS || '10000', 10);
+// Bodies are streamed to the backends; larger declared bodies get 413 here
+const MAX_BODY_BYTES = parseInt(process.env.MAX_BODY_BYTES || '65536', 10);
 const PRODUCT_SERVICE_URL =
   process.env.PRODUCT_SERVICE_URL ||
   'http://product-service.ecommerce-real.svc.cluster.local:8081';
 const CART_SERVICE_URL =
   process.env.CART_SERVICE_URL ||
   'http://cart-service.ecommerce-real.svc.cluster.local:8082';
+// /ready gives each backend this long to answer /health
+const READY_CHECK_TIMEOUT_MS = parseInt(process.env.READY_CHECK_TIMEOUT_MS || '2000', 10);
+// Opt-in request body logging. Only JSON and form bodies are logged, with
+// secret-looking fields redacted, truncated to LOG_BODY_MAX_CHARS
+const LOG_REQUEST_BODY = ['1', 'true', 'yes'].includes((process.env.LOG_REQUEST_BODY || '').toLowerCase());
+const LOG_BODY_MAX_CHARS = parseInt(process.env.LOG_BODY_MAX_CHARS || '1024', 10);
+const SECRET_FIELD_RE = /pass(word)?|passwd|pwd|secret|token|api[-_]?key|auth|credential|session|cookie|card|cvv|ssn/i;
 
 const app = express();
 
 // ---------------------------------------------------------------------------
 // JSON request logging to stdout
 // ---------------------------------------------------------------------------
+function redactSecrets(value) {
+  if (Array.isArray(value)) {
+    return value.map(redactSecrets);
+  }
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(
+      Object.entries(value).map(([key, v]) => [key, SECRET_FIELD_RE.test(key) ? '[redacted]' : redactSecrets(v)])
+    );
+  }
+  return value;
+}
+
+// Bodies that can't be parsed can't be redacted, so they're never logged verbatim
+function loggableBody(req, raw) {
+  const type = (req.headers['content-type'] || '').split(';')[0].trim().toLowerCase();
+  let parsed;
+  try {
+    if (type === 'application/json' || type.endsWith('+json')) {
+      parsed = JSON.parse(raw.toString('utf8'));
+    } else if (type === 'application/x-www-form-urlencoded') {
+      parsed = Object.fromEntries(new URLSearchParams(raw.toString('utf8')));
+    }
+  } catch (_err) {
+    parsed = undefined;
+  }
+  if (parsed === undefined) {
+    return { body: `[${type || 'unknown'} body not logged]`, body_truncated: false };
+  }
+  const text = JSON.stringify(redactSecrets(parsed));
+  return { body: text.slice(0, LOG_BODY_MAX_CHARS), body_truncated: text.length > LOG_BODY_MAX_CHARS };
+}
+
 app.use((req, res, next) => {
   const start = Date.now();
+  // Tap the body as it streams to the proxy, keeping at most MAX_BODY_BYTES
+  const bodyChunks = [];
+  let bodyBytes = 0;
+  if (LOG_REQUEST_BODY) {
+    req.on('data', (chunk) => {
+      if (bodyBytes < MAX_BODY_BYTES) {
+        bodyChunks.push(chunk);
+      }
+      bodyBytes += chunk.length;
+    });
+    // Adding a data listener starts the flow; hold it until the proxy pipes
+    req.pause();
+  }
   res.on('finish', () => {
     const logEntry = {
       timestamp: new Date().toISOString(),
       method: req.method,
       path: req.originalUrl,
-      source_ip: req.ip || req.socket.remoteAddress,
+      source_ip: req.headers['x-real-ip'] || req.ip || req.socket.remoteAddress,
       user_agent: req.headers['user-agent'] || '',
+      request_id: req.headers['x-request-id'] || '',
       response_code: res.statusCode,
       duration_ms: Date.now() - start,
     };
+    if (bodyBytes > 0) {
+      logEntry.body_bytes = bodyBytes;
+      if (bodyBytes > MAX_BODY_BYTES) {
+        logEntry.body = '[body over MAX_BODY_BYTES not logged]';
+        logEntry.body_truncated = true;
+      } else {
+        Object.assign(logEntry, loggableBody(req, Buffer.concat(bodyChunks)));
+      }
+    }
     process.stdout.write(JSON.stringify(logEntry) + '\n');
   });
   next();
@@ -55,6 +124,18 @@ app.use((_req, res, next) => {
   next();
 });
 
+// ---------------------------------------------------------------------------
+// Request body size limit
+// ---------------------------------------------------------------------------
+app.use((req, res, next) => {
+  const length = parseInt(req.headers['content-length'] || '0', 10);
+  if (length > MAX_BODY_BYTES) {
+    res.set('Connection', 'close');
+    return res.status(413).json({ error: 'Request body too large', max_bytes: MAX_BODY_BYTES });
+  }
+  next();
+});
+
 // ---------------------------------------------------------------------------
 // Health check
 // ---------------------------------------------------------------------------
@@ -62,6 +143,26 @@ app.get('/health', (_req, res) => {
   res.json({ status: 'healthy', service: 'frontend' });
 });
 
+async function checkDependency(url) {
+  try {
+    const res = await fetch(url, { signal: AbortSignal.timeout(READY_CHECK_TIMEOUT_MS) });
+    return res.ok ? { ok: true } : { ok: false, error: `HTTP ${res.status}` };
+  } catch (err) {
+    return { ok: false, error: err.message };
+  }
+}
+
+// Readiness: 503 unless both backends answer; /health stays a cheap liveness check
+app.get('/ready', async (_req, res) => {
+  const [productService, cartService] = await Promise.all([
+    checkDependency(`${PRODUCT_SERVICE_URL}/health`),
+    checkDependency(`${CART_SERVICE_URL}/health`),
+  ]);
+  const checks = { 'product-service': productService, 'cart-service': cartService };
+  const ok = Object.values(checks).every((check) => check.ok);
+  res.status(ok ? 200 : 503).json({ status: ok ? 'ready' : 'not_ready', service: 'frontend', checks });
+});
+
 // ---------------------------------------------------------------------------
 // API proxy: /api/products/* → product-service:8081
 // ---------------------------------------------------------------------------
@@ -129,7 +230,7 @@ app.get('*', (_req, res) => {
 // ---------------------------------------------------------------------------
 // Start server
 // ---------------------------------------------------------------------------
-app.listen(PORT, '0.0.0.0', () => {
+const server = app.listen(PORT, '0.0.0.0', () => {
   const startLog = {
     timestamp: new Date().toISOString(),
     level: 'INFO',
@@ -139,3 +240,25 @@ app.listen(PORT, '0.0.0.0', () => {
   };
   process.stdout.write(JSON.stringify(startLog) + '\n');
 });
+
+server.requestTimeout = REQUEST_TIMEOUT_MS;
+server.headersTimeout = Math.min(HEADERS_TIMEOUT_MS, REQUEST_TIMEOUT_MS);
+
+// ---------------------------------------------------------------------------
+// Graceful shutdown
+// ---------------------------------------------------------------------------
+function shutdown(signal) {
+  const log = {
+    timestamp: new Date().toISOString(),
+    level: 'INFO',
+    message: `Received ${signal}, draining connections (timeout ${SHUTDOWN_TIMEOUT_MS}ms)`,
+  };
+  process.stdout.write(JSON.stringify(log) + '\n');
+
+  setTimeout(() => process.exit(1), SHUTDOWN_TIMEOUT_MS).unref();
+  server.close(() => process.exit(0));
+  server.closeIdleConnections();
+}
+
+process.once('SIGTERM', () => shutdown('SIGTERM'));
+process.once('SIGINT', () => shutdown('SIGINT'));
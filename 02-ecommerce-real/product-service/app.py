@@ -108,6 +108,7 @@ def after_request(response):
         "path": request.path,
         "source_ip": request.remote_addr,
         "user_agent": request.headers.get("User-Agent", ""),
+        "request_id": request.headers.get("X-Request-ID", ""),
         "response_code": response.status_code,
         "duration_ms": duration_ms,
     }
@@ -143,6 +144,36 @@ def health():
     return jsonify({"status": "healthy", "service": "product-service"})
 
 
+@app.route("/ready")
+def ready():
+    """Readiness: 503 unless the database answers. /health stays a cheap liveness check."""
+    try:
+        cur = get_db().cursor()
+        cur.execute("SELECT 1")
+        cur.close()
+        checks = {"postgres": {"ok": True}}
+    except Exception as e:
+        # Don't hand a broken connection back to the pool
+        db = g.pop("db", None)
+        if db is not None:
+            try:
+                get_db_pool().putconn(db, close=True)
+            except Exception:
+                pass
+        checks = {"postgres": {"ok": False, "error": str(e).strip()[:200]}}
+    ok = checks["postgres"]["ok"]
+    return (
+        jsonify(
+            {
+                "status": "ready" if ok else "not_ready",
+                "service": "product-service",
+                "checks": checks,
+            }
+        ),
+        200 if ok else 503,
+    )
+
+
 @app.route("/api/products")
 def list_products():
     try:
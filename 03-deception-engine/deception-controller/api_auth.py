@@ -0,0 +1,43 @@
+"""
+Bearer-token check for the operator /api/* endpoints.
+
+Each image is built from its own directory, so this file is kept identical
+in deception-controller/ and traffic-analyzer/; test_api_auth.py fails if
+the two copies drift apart.
+"""
+
+import hmac
+from functools import wraps
+
+from flask import jsonify, request
+
+
+def check_bearer(authorization, token, setting):
+    """
+    Return None if the Authorization header carries ``token``, else
+    (status, message). Fails closed: with no token configured every request
+    gets 503, naming the ``setting`` that is missing.
+    """
+    if not token:
+        return 503, f"{setting} is not configured"
+    expected = f"Bearer {token}".encode()
+    if not hmac.compare_digest((authorization or "").encode(), expected):
+        return 401, "unauthorized"
+    return None
+
+
+def require_bearer_token(token, setting):
+    """Decorator rejecting requests without "Authorization: Bearer <token>"."""
+
+    def decorator(view):
+        @wraps(view)
+        def wrapper(*args, **kwargs):
+            error = check_bearer(request.headers.get("Authorization"), token, setting)
+            if error:
+                status, message = error
+                return jsonify({"error": message}), status
+            return view(*args, **kwargs)
+
+        return wrapper
+
+    return decorator
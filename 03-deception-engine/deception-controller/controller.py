@@ -15,22 +15,33 @@ Uses the official kubernetes Python client with in-cluster ServiceAccount
 authentication (deception-controller SA from rbac.yaml).
 """
 
+import ipaddress
 import json
 import logging
 import os
 import sys
 import threading
 import time
+import urllib.request
 import uuid
-from collections import defaultdict
+from collections import OrderedDict, defaultdict, deque
+from concurrent.futures import ThreadPoolExecutor
 from datetime import datetime, timezone
+from functools import wraps
 
 import redis
 from flask import Flask, g, jsonify, request
-from kubernetes import client, config
+from kubernetes import client, config, watch
 from kubernetes.client.rest import ApiException
 
-from decoy_templates import create_decoy_set
+from api_auth import require_bearer_token
+from decoy_templates import (
+    DEFAULT_TTL_MINUTES,
+    INVALID_IMAGE_PULL_POLICY,
+    create_decoy_set,
+    profile_for,
+    sanitize_ip_label,
+)
 
 # ---------------------------------------------------------------------------
 # App setup
@@ -49,19 +60,31 @@ class JsonFormatter(logging.Formatter):
             "service": "deception-controller",
             "message": record.getMessage(),
         }
+        # Contextual fields passed via logger.info(..., extra={"fields": {...}})
+        log_record.update(getattr(record, "fields", {}))
         return json.dumps(log_record)
 
 
+LOG_LEVEL = getattr(
+    logging, os.environ.get("LOG_LEVEL", "INFO").upper(), logging.INFO
+)
+
 handler = logging.StreamHandler(sys.stdout)
 handler.setFormatter(JsonFormatter())
 app.logger.handlers = [handler]
-app.logger.setLevel(logging.INFO)
+app.logger.setLevel(LOG_LEVEL)
 logging.getLogger("werkzeug").setLevel(logging.WARNING)
 
 # Also configure the root logger for non-Flask code paths
 root_logger = logging.getLogger("controller")
 root_logger.handlers = [handler]
-root_logger.setLevel(logging.INFO)
+root_logger.setLevel(LOG_LEVEL)
+
+if INVALID_IMAGE_PULL_POLICY:
+    root_logger.warning(
+        f"DECOY_IMAGE_PULL_POLICY={INVALID_IMAGE_PULL_POLICY!r} is not Always, "
+        "IfNotPresent or Never; using Never"
+    )
 
 # ---------------------------------------------------------------------------
 # Configuration
@@ -71,17 +94,90 @@ REDIS_URL = os.environ.get(
 )
 DECOY_NAMESPACE = os.environ.get("DECOY_NAMESPACE", "decoy-pool")
 PORT = int(os.environ.get("PORT", "8086"))
+# Dry-run: compute and report decoy sets without creating, evicting, or
+# routing anything — for evaluating detections in a shared cluster
+DRY_RUN = os.environ.get("DRY_RUN", "false").lower() in ("1", "true", "yes")
 
 # Channel names
 CH_ATTACK_DETECTED = "attack_detected"
 CH_DECOY_SPAWNED = "decoy_spawned"
 CH_ROUTING_UPDATE = "routing_update"
 
+# Redis hash mirroring active routes (attacker_ip -> {"url", "expires_at"}
+# JSON) so the traffic-router can rebuild its in-memory table after a restart
+ROUTES_KEY = "deception:routes"
+
 # Limits
-MAX_DECOY_PODS = 15  # matches decoy-pool ResourceQuota
-MAX_DECOY_SETS = 5  # 15 pods / 3 pods per set
+MAX_DECOY_PODS = int(os.environ.get("MAX_DECOY_PODS", "15"))  # keep <= decoy-pool ResourceQuota
+MAX_DECOY_SETS = MAX_DECOY_PODS // 3  # 3 pods per set
+# Alerts below this analyzer confidence are recorded but get no decoys
+# (0 = every alert that passed the analyzer's own threshold)
+MIN_DECOY_CONFIDENCE = float(os.environ.get("MIN_DECOY_CONFIDENCE", "0"))
+# At capacity: "evict" the oldest set to make room, or "throttle" (skip the
+# new attacker until a set expires; its next alert retries)
+CAPACITY_POLICY = os.environ.get("DECOY_CAPACITY_POLICY", "evict").lower()
+# Decoy resources created in parallel per set, and the stagger between
+# successive create calls to smooth API server load
+DECOY_CREATE_CONCURRENCY = max(1, int(os.environ.get("DECOY_CREATE_CONCURRENCY", "3")))
+DECOY_CREATE_DELAY_MS = max(0, int(os.environ.get("DECOY_CREATE_DELAY_MS", "0")))
+# Dead decoy pods are deleted and recreated from the set's template, up to
+# this many times per set; past that the set is marked degraded (0 disables)
+DECOY_MAX_RECREATES = max(0, int(os.environ.get("DECOY_MAX_RECREATES", "2")))
 POD_READY_TIMEOUT = 120  # seconds to wait for pods to become Ready
 TTL_CHECK_INTERVAL = 60  # seconds between TTL cleanup sweeps
+POD_WATCH_TIMEOUT = 300  # seconds before the decoy pod watch is re-established
+# Container waiting/terminated reasons that mark a decoy pod as dead; the
+# kubelet keeps restarting it, but it never serves the attacker again
+UNHEALTHY_WAITING_REASONS = {
+    "CrashLoopBackOff",
+    "ImagePullBackOff",
+    "ErrImagePull",
+    "CreateContainerConfigError",
+}
+UNHEALTHY_TERMINATED_REASONS = {"OOMKilled"}
+# Optional webhook (generic JSON or Slack incoming-webhook) for alerts and
+# decoy creations at or above NOTIFY_MIN_SEVERITY
+NOTIFY_WEBHOOK_URL = os.environ.get("NOTIFY_WEBHOOK_URL", "")
+NOTIFY_FORMAT = os.environ.get("NOTIFY_FORMAT", "json").lower()
+NOTIFY_MIN_SEVERITY = os.environ.get("NOTIFY_MIN_SEVERITY", "critical").lower()
+NOTIFY_TIMEOUT_SECONDS = float(os.environ.get("NOTIFY_TIMEOUT_SECONDS", "5"))
+SEVERITY_LEVELS = ["low", "medium", "high", "critical"]
+# Alerts and lifecycle events kept in memory for /api/timeline
+TIMELINE_SIZE = int(os.environ.get("TIMELINE_SIZE", "1000"))
+# Bearer token for the mutating /api/* endpoints; they answer 503 when unset
+CONTROLLER_API_TOKEN = os.environ.get("CONTROLLER_API_TOKEN", "")
+# Upper bound for ttl_minutes on manual deploys
+MAX_MANUAL_TTL_MINUTES = int(os.environ.get("MAX_MANUAL_TTL_MINUTES", "240"))
+# Repeats of the same source_ip + attack_type alert within this window are
+# dropped before any processing (0 disables); the cache is bounded
+ALERT_DEDUP_SECONDS = float(os.environ.get("ALERT_DEDUP_SECONDS", "30"))
+ALERT_DEDUP_MAX_KEYS = 10000
+# After a failed decoy spawn (API error, quota), further alerts for that IP
+# skip spawning for a backoff that doubles per consecutive failure up to
+# the max, and resets once a spawn succeeds
+SPAWN_BACKOFF_BASE_SECONDS = float(os.environ.get("SPAWN_BACKOFF_BASE_SECONDS", "5"))
+SPAWN_BACKOFF_MAX_SECONDS = float(os.environ.get("SPAWN_BACKOFF_MAX_SECONDS", "300"))
+# When false, alerts are recorded and notified but spawn no decoys; only
+# POST /api/deploy creates them (e.g. while tuning detection rules)
+AUTO_DEPLOY = os.environ.get("AUTO_DEPLOY", "true").lower() in ("1", "true", "yes")
+# Comma-separated origins allowed to call the API from a browser ("*" for
+# any); unset = same-origin only
+CORS_ALLOWED_ORIGINS = [
+    origin.strip()
+    for origin in os.environ.get("CORS_ALLOWED_ORIGINS", "").split(",")
+    if origin.strip()
+]
+# Body cap for the /api/* JSON requests
+MAX_BODY_BYTES = int(os.environ.get("MAX_BODY_BYTES", str(64 * 1024)))
+app.config["MAX_CONTENT_LENGTH"] = MAX_BODY_BYTES
+# Bounds the Redis ping and Kubernetes pod list behind /ready
+READY_CHECK_TIMEOUT = float(os.environ.get("READY_CHECK_TIMEOUT", "2"))
+
+if not CONTROLLER_API_TOKEN:
+    root_logger.warning(
+        "CONTROLLER_API_TOKEN is not set; /api/deploy and /api/unblock are "
+        "disabled (503) until the deception-controller-api-token secret exists"
+    )
 
 # ---------------------------------------------------------------------------
 # Shared state
@@ -91,12 +187,37 @@ controller_stats = {
     "total_cleaned_sets": 0,
     "total_attacks_received": 0,
     "total_duplicate_skipped": 0,
+    "total_invalid_skipped": 0,
     "total_evictions": 0,
+    "total_throttled": 0,
+    "total_low_confidence_skipped": 0,
+    "total_deduplicated": 0,
+    "total_auto_deploy_skipped": 0,
+    "total_spawn_failures": 0,
+    "total_backoff_skipped": 0,
+    "alerts_by_type": defaultdict(int),
+    "alerts_by_severity": defaultdict(int),
     "started_at": datetime.now(timezone.utc).isoformat(),
     "active_decoy_sets": {},  # attack_id_short -> {attacker_ip, attack_type, created_at, pods: [...]}
 }
 stats_lock = threading.Lock()
 
+# (source_ip, attack_type) -> monotonic time of the last accepted alert,
+# oldest first; guarded by stats_lock
+recent_alerts = OrderedDict()
+
+# source_ip -> {"failures": consecutive failed spawns, "retry_at": monotonic
+# time spawning may be retried}, oldest first; guarded by stats_lock
+spawn_failures = OrderedDict()
+
+# Last known health of each decoy pod (pod name -> "healthy" or the reason it
+# is not), kept by the pod watcher so transitions are broadcast only once
+decoy_pod_health = {}
+
+# Bounded, chronological record of everything the controller saw or did
+timeline = deque(maxlen=TIMELINE_SIZE)
+timeline_lock = threading.Lock()
+
 # ---------------------------------------------------------------------------
 # Kubernetes client
 # ---------------------------------------------------------------------------
@@ -155,8 +276,16 @@ def get_redis_publisher():
         return None
 
 
+def record_timeline(event):
+    """Append an event to the in-memory timeline served by /api/timeline."""
+    with timeline_lock:
+        timeline.append(dict(event))
+
+
 def publish_event(channel, event):
     """Publish a JSON event to a Redis channel. Fails silently."""
+    # Recorded before publishing so the timeline survives a Redis outage
+    record_timeline(event)
     client = get_redis_publisher()
     if client is None:
         return
@@ -168,6 +297,105 @@ def publish_event(channel, event):
         redis_publisher = None
 
 
+def publish_route_update(event):
+    """
+    Publish a routing_update event and mirror it into the ROUTES_KEY hash.
+
+    add_route stores {"url": <frontend service>, "expires_at": <epoch>},
+    the expiry taken from the event's ttl_seconds, so a route whose
+    remove_route is missed isn't restored forever. remove_route drops the
+    attacker's entry and/or every entry pointing at the attack's decoys.
+    Fails silently like publish_event; the pub/sub message is still sent.
+    """
+    client = get_redis_publisher()
+    if client is not None:
+        try:
+            if event["type"] == "add_route":
+                entry = {"url": event["frontend_service"]}
+                if event.get("ttl_seconds"):
+                    entry["expires_at"] = int(time.time()) + event["ttl_seconds"]
+                client.hset(ROUTES_KEY, event["attacker_ip"], json.dumps(entry))
+            elif event["type"] == "remove_route":
+                if event.get("attacker_ip"):
+                    client.hdel(ROUTES_KEY, event["attacker_ip"])
+                if event.get("attack_id"):
+                    for ip, target in client.hgetall(ROUTES_KEY).items():
+                        if event["attack_id"] in target.decode():
+                            client.hdel(ROUTES_KEY, ip)
+        except redis.RedisError as e:
+            root_logger.warning(f"Redis route store update failed: {e}")
+    publish_event(CH_ROUTING_UPDATE, event)
+
+
+# ---------------------------------------------------------------------------
+# Webhook notifications
+# ---------------------------------------------------------------------------
+def severity_of(event):
+    """
+    The event's severity as set by the analyzer, which owns the confidence
+    cut-offs; "low" when it is missing or not a known level.
+    """
+    severity = event.get("severity")
+    return severity if severity in SEVERITY_LEVELS else "low"
+
+
+def _build_notification(event, severity):
+    """Render an event as a compact webhook body (generic JSON or Slack)."""
+    if event["type"] == "alert":
+        text = (
+            f"[{severity.upper()}] {event.get('attack_type')} attack from "
+            f"{event.get('attacker_ip')} (confidence {event.get('confidence')})"
+        )
+    else:
+        text = (
+            f"[{severity.upper()}] Decoy set {str(event.get('attack_id'))[:8]} "
+            f"deployed for {event.get('attacker_ip')} ({event.get('attack_type')})"
+        )
+    if NOTIFY_FORMAT == "slack":
+        return {"text": text}
+    return {
+        "source": "deception-controller",
+        "severity": severity,
+        "text": text,
+        "event": event,
+    }
+
+
+def _post_notification(body):
+    try:
+        req = urllib.request.Request(
+            NOTIFY_WEBHOOK_URL,
+            data=json.dumps(body).encode(),
+            headers={"Content-Type": "application/json"},
+            method="POST",
+        )
+        with urllib.request.urlopen(req, timeout=NOTIFY_TIMEOUT_SECONDS) as resp:
+            resp.read()
+    except Exception as e:
+        root_logger.warning(f"Webhook notification dropped: {e}")
+
+
+def notify(event, severity):
+    """
+    POST an event to NOTIFY_WEBHOOK_URL if its severity is high enough.
+
+    Delivery runs on its own thread so a slow or dead webhook never holds up
+    decoy creation; failures are logged and the notification is dropped.
+    """
+    if not NOTIFY_WEBHOOK_URL:
+        return
+    if SEVERITY_LEVELS.index(severity) < SEVERITY_LEVELS.index(
+        NOTIFY_MIN_SEVERITY if NOTIFY_MIN_SEVERITY in SEVERITY_LEVELS else "critical"
+    ):
+        return
+    threading.Thread(
+        target=_post_notification,
+        args=(_build_notification(event, severity),),
+        daemon=True,
+        name="webhook-notify",
+    ).start()
+
+
 # ============================================================================
 # Core: Decoy spawning logic
 # ============================================================================
@@ -212,7 +440,7 @@ def _get_decoy_pod_count():
 
 def _has_existing_decoys_for_ip(attacker_ip):
     """Check if decoys already exist for a given attacker IP."""
-    safe_ip = attacker_ip.replace(":", "-")
+    safe_ip = sanitize_ip_label(attacker_ip)
     k8s = get_k8s_client()
     if k8s is None:
         return False
@@ -229,7 +457,7 @@ def _has_existing_decoys_for_ip(attacker_ip):
 
 def _get_existing_attack_short_for_ip(attacker_ip):
     """Return existing short attack-id for a given attacker IP, or None."""
-    safe_ip = attacker_ip.replace(":", "-")
+    safe_ip = sanitize_ip_label(attacker_ip)
     k8s = get_k8s_client()
     if k8s is None:
         return None
@@ -249,6 +477,31 @@ def _get_existing_attack_short_for_ip(attacker_ip):
     return None
 
 
+def _get_attack_sets_for_ip(attacker_ip):
+    """Short attack-ids of every decoy set, tracked or in the cluster, for an IP."""
+    with stats_lock:
+        short_ids = {
+            short_id
+            for short_id, info in controller_stats["active_decoy_sets"].items()
+            if info.get("attacker_ip") == attacker_ip
+        }
+    k8s = get_k8s_client()
+    if k8s is None or DRY_RUN:
+        return short_ids
+    try:
+        pods = k8s.list_namespaced_pod(
+            namespace=DECOY_NAMESPACE,
+            label_selector=f"role=decoy,attacker-ip={sanitize_ip_label(attacker_ip)}",
+        )
+        for pod in pods.items:
+            attack_short = (pod.metadata.labels or {}).get("attack-id", "")
+            if attack_short:
+                short_ids.add(attack_short)
+    except ApiException as e:
+        root_logger.error(f"Failed to list decoys for IP {attacker_ip}: {e.status}")
+    return short_ids
+
+
 def _is_attack_set_ready(attack_id_short):
     """Return True when all pods in a decoy set report Ready=True."""
     if not attack_id_short:
@@ -278,6 +531,44 @@ def _is_attack_set_ready(attack_id_short):
         return False
 
 
+def _remaining_ttl_seconds(annotations, now=None):
+    """
+    Seconds until a decoy set's TTL runs out, from one of its pods'
+    annotations. Sent as ttl_seconds on add_route so the router's route
+    lives exactly as long as the decoys. Never below 1, so a set about to
+    be cleaned up still gets a short route rather than the router default.
+    """
+    now = now or datetime.now(timezone.utc)
+    try:
+        created_at = datetime.fromisoformat(annotations["deception-system/created-at"])
+        ttl_minutes = int(annotations["deception-system/ttl-minutes"])
+    except (KeyError, ValueError, TypeError):
+        return DEFAULT_TTL_MINUTES * 60
+    return max(int(ttl_minutes * 60 - (now - created_at).total_seconds()), 1)
+
+
+def _attack_set_ttl_seconds(attack_id_short):
+    """Remaining TTL of a decoy set in the cluster (default TTL if unknown)."""
+    k8s = get_k8s_client()
+    if k8s is None:
+        return DEFAULT_TTL_MINUTES * 60
+    try:
+        pods = k8s.list_namespaced_pod(
+            namespace=DECOY_NAMESPACE,
+            label_selector=f"role=decoy,attack-id={attack_id_short}",
+        )
+    except ApiException as e:
+        root_logger.error(
+            f"Failed TTL lookup for attack-id {attack_id_short}: {e.status}"
+        )
+        return DEFAULT_TTL_MINUTES * 60
+    if not pods.items:
+        return DEFAULT_TTL_MINUTES * 60
+    return min(
+        _remaining_ttl_seconds(pod.metadata.annotations or {}) for pod in pods.items
+    )
+
+
 def _find_oldest_attack_set():
     """
     Find the oldest decoy set by created-at annotation.
@@ -411,6 +702,175 @@ def _wait_for_pods_running(pod_names, timeout=POD_READY_TIMEOUT):
     return False
 
 
+def _create_resource(k8s, resource):
+    """Create one Pod or Service. Returns the ApiException on failure, else None."""
+    try:
+        if resource.get("kind") == "Pod":
+            k8s.create_namespaced_pod(namespace=DECOY_NAMESPACE, body=resource)
+        elif resource.get("kind") == "Service":
+            k8s.create_namespaced_service(namespace=DECOY_NAMESPACE, body=resource)
+        return None
+    except ApiException as e:
+        return e
+
+
+def _create_resources(k8s, resources):
+    """
+    Create a decoy set's resources with at most DECOY_CREATE_CONCURRENCY
+    requests in flight, submitting one every DECOY_CREATE_DELAY_MS so a set
+    doesn't land on the API server as a single burst.
+
+    Every resource is attempted regardless of earlier failures. Returns
+    [(resource, ApiException | None), ...] in the order given.
+    """
+    delay = DECOY_CREATE_DELAY_MS / 1000.0
+    futures = []
+    with ThreadPoolExecutor(
+        max_workers=DECOY_CREATE_CONCURRENCY, thread_name_prefix="decoy-create"
+    ) as pool:
+        for i, resource in enumerate(resources):
+            if i and delay:
+                time.sleep(delay)
+            futures.append(pool.submit(_create_resource, k8s, resource))
+    return [(r, f.result()) for r, f in zip(resources, futures)]
+
+
+def _validate_attack_event(event_data):
+    """
+    Check that an attack event carries a usable attacker IP.
+
+    Returns an error message, or None if the event is valid. Without a real
+    IP the decoy set would be labelled for nobody and no route could ever
+    be added for it.
+    """
+    source_ip = event_data.get("source_ip")
+    if not source_ip or not isinstance(source_ip, str):
+        return "missing source_ip"
+    try:
+        address = ipaddress.ip_address(source_ip)
+    except ValueError:
+        return f"invalid source_ip {source_ip!r}"
+    # A zone ("fe80::1%eth0") only means something on the router's own link,
+    # and "%" can't appear in the attacker-ip label
+    if getattr(address, "scope_id", None):
+        return f"scoped source_ip {source_ip!r} not supported"
+    return None
+
+
+def _record_dry_run_set(resources, attack_id, source_ip, attack_type):
+    """
+    Track and announce a decoy set without touching the cluster.
+
+    Mirrors the bookkeeping of a real spawn so /status and the dashboard
+    show what would have been created; no routing update is published.
+    """
+    pods = [r["metadata"]["name"] for r in resources if r.get("kind") == "Pod"]
+    services = [
+        r["metadata"]["name"] for r in resources if r.get("kind") == "Service"
+    ]
+    short_id = attack_id[:8]
+
+    with stats_lock:
+        # No pods exist to dedupe against, so check the tracked sets instead
+        if any(
+            info.get("attacker_ip") == source_ip
+            for info in controller_stats["active_decoy_sets"].values()
+        ):
+            controller_stats["total_duplicate_skipped"] += 1
+            root_logger.info(f"[dry-run] Decoys already planned for IP {source_ip}")
+            return
+        controller_stats["total_spawned_sets"] += 1
+        controller_stats["active_decoy_sets"][short_id] = {
+            "attack_id": attack_id,
+            "attacker_ip": source_ip,
+            "attack_type": attack_type,
+            "profile": profile_for(attack_type),
+            "created_at": datetime.now(timezone.utc).isoformat(),
+            "pods": pods,
+            "services": services,
+            "pods_ready": False,
+            "dry_run": True,
+        }
+
+    publish_event(
+        CH_DECOY_SPAWNED,
+        {
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "type": "decoy_spawned",
+            "attack_id": attack_id,
+            "attacker_ip": source_ip,
+            "attack_type": attack_type,
+            "decoy_pods": pods,
+            "decoy_services": services,
+            "pods_ready": False,
+            "dry_run": True,
+        },
+    )
+
+    root_logger.info(
+        f"[dry-run] Would create decoy set: attack={short_id} ip={source_ip} "
+        f"pods={pods} services={services}"
+    )
+
+
+def _is_duplicate_alert(source_ip, attack_type):
+    """
+    True if the same source_ip + attack_type was accepted within
+    ALERT_DEDUP_SECONDS; otherwise remember this alert and return False.
+    """
+    if ALERT_DEDUP_SECONDS <= 0:
+        return False
+    key = (source_ip, attack_type)
+    now = time.monotonic()
+    with stats_lock:
+        last = recent_alerts.get(key)
+        if last is not None and now - last < ALERT_DEDUP_SECONDS:
+            controller_stats["total_deduplicated"] += 1
+            return True
+        recent_alerts[key] = now
+        recent_alerts.move_to_end(key)
+        while len(recent_alerts) > ALERT_DEDUP_MAX_KEYS:
+            recent_alerts.popitem(last=False)
+    return False
+
+
+def _spawn_backoff_remaining(source_ip):
+    """Seconds before decoys may be spawned again for source_ip (0 = now)."""
+    with stats_lock:
+        entry = spawn_failures.get(source_ip)
+        if entry is None:
+            return 0.0
+        return max(0.0, entry["retry_at"] - time.monotonic())
+
+
+def _record_spawn_result(source_ip, succeeded):
+    """
+    Reset source_ip's spawn backoff on success; on failure double it (up to
+    SPAWN_BACKOFF_MAX_SECONDS) and return the new delay in seconds.
+    """
+    with stats_lock:
+        if succeeded:
+            spawn_failures.pop(source_ip, None)
+            return 0.0
+        entry = spawn_failures.pop(source_ip, None) or {"failures": 0}
+        entry["failures"] += 1
+        delay = min(
+            SPAWN_BACKOFF_BASE_SECONDS * 2 ** min(entry["failures"] - 1, 30),
+            SPAWN_BACKOFF_MAX_SECONDS,
+        )
+        entry["retry_at"] = time.monotonic() + delay
+        spawn_failures[source_ip] = entry
+        while len(spawn_failures) > ALERT_DEDUP_MAX_KEYS:
+            spawn_failures.popitem(last=False)
+        controller_stats["total_spawn_failures"] += 1
+    root_logger.warning(
+        f"Decoy spawn failed for {source_ip} ({entry['failures']} in a row), "
+        f"backing off {delay:.0f}s",
+        extra={"fields": {"source_ip": source_ip, "failures": entry["failures"]}},
+    )
+    return delay
+
+
 def handle_attack_event(event_data):
     """
     Process an attack_detected event: spawn decoys if appropriate.
@@ -425,9 +885,75 @@ def handle_attack_event(event_data):
     with stats_lock:
         controller_stats["total_attacks_received"] += 1
 
+    error = _validate_attack_event(event_data)
+    if error:
+        root_logger.warning(f"Rejected attack event: {error}")
+        with stats_lock:
+            controller_stats["total_invalid_skipped"] += 1
+        return
+
+    # Manual deploys are explicit requests and are never deduplicated
+    if event_data.get("source") != "manual" and _is_duplicate_alert(
+        source_ip, attack_type
+    ):
+        root_logger.debug(
+            f"Duplicate alert within {ALERT_DEDUP_SECONDS}s: type={attack_type} ip={source_ip}",
+            extra={"fields": {"source_ip": source_ip, "attack_type": attack_type}},
+        )
+        return
+
     root_logger.info(
-        f"Attack event: type={attack_type} ip={source_ip} id={attack_id[:8]}"
+        f"Attack event: type={attack_type} ip={source_ip} id={attack_id[:8]}",
+        extra={
+            "fields": {
+                "attack_id": attack_id[:8],
+                "source_ip": source_ip,
+                "attack_type": attack_type,
+                "request_id": (event_data.get("request") or {}).get("request_id", ""),
+            }
+        },
     )
+    severity = severity_of(event_data)
+    alert = {
+        "timestamp": event_data.get("timestamp")
+        or datetime.now(timezone.utc).isoformat(),
+        "type": "alert",
+        "attack_id": attack_id,
+        "attacker_ip": source_ip,
+        "attack_type": attack_type,
+        "confidence": event_data.get("confidence"),
+        "severity": severity,
+        "country": event_data.get("country"),
+        "asn": event_data.get("asn"),
+        "source": event_data.get("source", "analyzer"),
+    }
+    record_timeline(alert)
+    with stats_lock:
+        controller_stats["alerts_by_type"][attack_type] += 1
+        controller_stats["alerts_by_severity"][severity] += 1
+
+    try:
+        confidence = float(event_data.get("confidence") or 0)
+    except (TypeError, ValueError):
+        confidence = 0.0
+    if confidence < MIN_DECOY_CONFIDENCE:
+        root_logger.info(
+            f"Confidence {confidence} below MIN_DECOY_CONFIDENCE={MIN_DECOY_CONFIDENCE}, no decoys for {source_ip}",
+            extra={"fields": {"attack_id": attack_id[:8], "source_ip": source_ip}},
+        )
+        with stats_lock:
+            controller_stats["total_low_confidence_skipped"] += 1
+        return
+
+    if not AUTO_DEPLOY and event_data.get("source") != "manual":
+        root_logger.info(
+            f"AUTO_DEPLOY is off, alert only for {source_ip}",
+            extra={"fields": {"attack_id": attack_id[:8], "source_ip": source_ip}},
+        )
+        notify(alert, severity)
+        with stats_lock:
+            controller_stats["total_auto_deploy_skipped"] += 1
+        return
 
     # --- Check for duplicate: already have decoys for this IP ---
     if _has_existing_decoys_for_ip(source_ip):
@@ -435,8 +961,7 @@ def handle_attack_event(event_data):
         existing_short = _get_existing_attack_short_for_ip(source_ip)
         if existing_short and _is_attack_set_ready(existing_short):
             # Re-publish route in case router restarted or initial route publish was skipped.
-            publish_event(
-                CH_ROUTING_UPDATE,
+            publish_route_update(
                 {
                     "timestamp": datetime.now(timezone.utc).isoformat(),
                     "type": "add_route",
@@ -445,6 +970,7 @@ def handle_attack_event(event_data):
                     "frontend_service": f"decoy-fe-{existing_short}.{DECOY_NAMESPACE}.svc.cluster.local:3000",
                     "api_service": f"decoy-api-{existing_short}.{DECOY_NAMESPACE}.svc.cluster.local:8081",
                     "db_service": f"decoy-db-{existing_short}.{DECOY_NAMESPACE}.svc.cluster.local:5432",
+                    "ttl_seconds": _attack_set_ttl_seconds(existing_short),
                 },
             )
             root_logger.info(
@@ -454,15 +980,61 @@ def handle_attack_event(event_data):
             controller_stats["total_duplicate_skipped"] += 1
         return
 
-    # --- Resource guard: evict oldest if at capacity ---
+    # Recent spawns for this IP failed; don't hammer the API server with
+    # retries on every alert (manual deploys retry immediately)
+    backoff = _spawn_backoff_remaining(source_ip)
+    if backoff > 0 and event_data.get("source") != "manual":
+        root_logger.info(
+            f"Spawn backoff for {source_ip}, retrying in {backoff:.0f}s",
+            extra={"fields": {"attack_id": attack_id[:8], "source_ip": source_ip}},
+        )
+        with stats_lock:
+            controller_stats["total_backoff_skipped"] += 1
+        return
+
+    # Only page for attackers that don't already have decoys, so a noisy IP
+    # produces one notification instead of one per request
+    notify(alert, severity)
+
+    # --- Resource guard: evict oldest (or throttle) if at capacity ---
     current_count = _get_decoy_pod_count()
-    if current_count >= MAX_DECOY_PODS - 2:
+    if current_count >= MAX_DECOY_PODS - 2 and not DRY_RUN and CAPACITY_POLICY == "throttle":
+        root_logger.warning(
+            f"At capacity ({current_count}/{MAX_DECOY_PODS} pods), throttling decoys for {source_ip}",
+            extra={
+                "fields": {
+                    "attack_id": attack_id[:8],
+                    "source_ip": source_ip,
+                    "attack_type": attack_type,
+                }
+            },
+        )
+        publish_event(
+            CH_DECOY_SPAWNED,
+            {
+                "timestamp": datetime.now(timezone.utc).isoformat(),
+                "type": "decoy_throttled",
+                "attack_id": attack_id,
+                "attacker_ip": source_ip,
+                "reason": "capacity_limit",
+                "current_pods": current_count,
+                "max_pods": MAX_DECOY_PODS,
+            },
+        )
+        with stats_lock:
+            controller_stats["total_throttled"] += 1
+        return
+    if current_count >= MAX_DECOY_PODS - 2 and not DRY_RUN:
         # Need room for 3 new pods; evict the oldest set
         oldest = _find_oldest_attack_set()
         if oldest:
             root_logger.info(
                 f"At capacity ({current_count} pods), evicting oldest set: {oldest}"
             )
+            with stats_lock:
+                evicted_ip = (
+                    controller_stats["active_decoy_sets"].get(oldest, {})
+                ).get("attacker_ip")
             _delete_decoy_set(oldest)
             publish_event(
                 CH_DECOY_SPAWNED,
@@ -470,6 +1042,17 @@ def handle_attack_event(event_data):
                     "timestamp": datetime.now(timezone.utc).isoformat(),
                     "type": "decoy_evicted",
                     "attack_id": oldest,
+                    "attacker_ip": evicted_ip,
+                    "reason": "capacity_limit",
+                },
+            )
+            # Route by attack_id, as on TTL expiry, so only the evicted set's
+            # route goes and a newer set for the same IP keeps its own
+            publish_route_update(
+                {
+                    "timestamp": datetime.now(timezone.utc).isoformat(),
+                    "type": "remove_route",
+                    "attack_id": oldest,
                     "reason": "capacity_limit",
                 },
             )
@@ -478,54 +1061,62 @@ def handle_attack_event(event_data):
                 controller_stats["total_cleaned_sets"] += 1
 
     # --- Generate decoy resources ---
-    resources = create_decoy_set(attack_id, source_ip, attack_type)
+    resources = create_decoy_set(
+        attack_id, source_ip, attack_type, ttl_minutes=event_data.get("ttl_minutes")
+    )
+
+    if DRY_RUN:
+        _record_dry_run_set(resources, attack_id, source_ip, attack_type)
+        return
 
     # --- Apply to cluster ---
     k8s = get_k8s_client()
     if k8s is None:
         root_logger.error("Kubernetes client unavailable, cannot spawn decoys")
+        _record_spawn_result(source_ip, False)
         return
 
     created_pods = []
     created_services = []
     quota_failure = False
 
-    for resource in resources:
+    # Fan the creates out over a small pool; results come back in resource
+    # order so the pod/service lists stay stable
+    failures = []
+    for resource, error in _create_resources(k8s, resources):
         kind = resource.get("kind", "")
         name = resource["metadata"]["name"]
+        if error is None:
+            (created_pods if kind == "Pod" else created_services).append(name)
+            root_logger.info(f"Created {kind.lower()}: {name}")
+            continue
+        failures.append(f"{kind} {name}: {error.status} {error.reason}")
+        root_logger.error(
+            f"Failed to create {kind} {name}: {error.status} {error.reason} — {error.body}"
+        )
+        if error.status == 403 and "exceeded quota" in str(error.body):
+            quota_failure = True
 
-        try:
-            if kind == "Pod":
-                k8s.create_namespaced_pod(
-                    namespace=DECOY_NAMESPACE,
-                    body=resource,
-                )
-                created_pods.append(name)
-                root_logger.info(f"Created pod: {name}")
-            elif kind == "Service":
-                k8s.create_namespaced_service(
-                    namespace=DECOY_NAMESPACE,
-                    body=resource,
-                )
-                created_services.append(name)
-                root_logger.info(f"Created service: {name}")
-        except ApiException as e:
-            root_logger.error(
-                f"Failed to create {kind} {name}: {e.status} {e.reason} — {e.body}"
-            )
-            if e.status == 403 and "exceeded quota" in str(e.body):
-                quota_failure = True
+    if failures:
+        root_logger.warning(
+            f"{len(failures)}/{len(resources)} decoy resources failed for attack "
+            f"{attack_id[:8]}: {'; '.join(failures)}",
+            extra={"fields": {"attack_id": attack_id[:8], "failures": failures}},
+        )
 
     if quota_failure and len(created_pods) < 3:
         root_logger.warning(
             f"Partial creation due to quota ({len(created_pods)} pods), cleaning up attack {attack_id[:8]}"
         )
         _delete_decoy_set(attack_id[:8])
+        _record_spawn_result(source_ip, False)
         return
 
     if not created_pods:
         root_logger.error("No pods were created, aborting decoy set")
+        _record_spawn_result(source_ip, False)
         return
+    _record_spawn_result(source_ip, True)
 
     # --- Wait for pods to be Ready ---
     root_logger.info(f"Waiting for {len(created_pods)} pods to reach Ready state...")
@@ -546,31 +1137,37 @@ def handle_attack_event(event_data):
             "attack_id": attack_id,
             "attacker_ip": source_ip,
             "attack_type": attack_type,
+            "profile": profile_for(attack_type),
             "created_at": datetime.now(timezone.utc).isoformat(),
             "pods": created_pods,
             "services": created_services,
             "pods_ready": pods_ready,
+            # Seeded from anything the pod watcher saw while we waited
+            "pod_health": {
+                name: decoy_pod_health[name]
+                for name in created_pods
+                if name in decoy_pod_health
+            },
         }
 
     # --- Publish decoy_spawned event ---
-    publish_event(
-        CH_DECOY_SPAWNED,
-        {
-            "timestamp": datetime.now(timezone.utc).isoformat(),
-            "type": "decoy_spawned",
-            "attack_id": attack_id,
-            "attacker_ip": source_ip,
-            "attack_type": attack_type,
-            "decoy_pods": created_pods,
-            "decoy_services": created_services,
-            "pods_ready": pods_ready,
-        },
-    )
+    spawned = {
+        "timestamp": datetime.now(timezone.utc).isoformat(),
+        "type": "decoy_spawned",
+        "attack_id": attack_id,
+        "attacker_ip": source_ip,
+        "attack_type": attack_type,
+        "profile": profile_for(attack_type),
+        "decoy_pods": created_pods,
+        "decoy_services": created_services,
+        "pods_ready": pods_ready,
+    }
+    publish_event(CH_DECOY_SPAWNED, spawned)
+    notify(spawned, severity)
 
     # --- Notify traffic-router to redirect this IP only when decoys are Ready ---
     if pods_ready:
-        publish_event(
-            CH_ROUTING_UPDATE,
+        publish_route_update(
             {
                 "timestamp": datetime.now(timezone.utc).isoformat(),
                 "type": "add_route",
@@ -579,12 +1176,27 @@ def handle_attack_event(event_data):
                 "frontend_service": f"decoy-fe-{short_id}.{DECOY_NAMESPACE}.svc.cluster.local:3000",
                 "api_service": f"decoy-api-{short_id}.{DECOY_NAMESPACE}.svc.cluster.local:8081",
                 "db_service": f"decoy-db-{short_id}.{DECOY_NAMESPACE}.svc.cluster.local:5432",
+                "ttl_seconds": _remaining_ttl_seconds(
+                    next(
+                        r["metadata"]["annotations"]
+                        for r in resources
+                        if r["kind"] == "Pod"
+                    )
+                ),
             },
         )
 
     root_logger.info(
         f"Decoy set complete: attack={short_id} ip={source_ip} "
-        f"pods={created_pods} services={created_services}"
+        f"pods={created_pods} services={created_services}",
+        extra={
+            "fields": {
+                "attack_id": short_id,
+                "source_ip": source_ip,
+                "attack_type": attack_type,
+                "pods_ready": pods_ready,
+            }
+        },
     )
 
 
@@ -593,6 +1205,20 @@ def handle_attack_event(event_data):
 # ============================================================================
 
 
+def _expire_dry_run_sets(now):
+    """Drop tracked dry-run sets older than the default decoy TTL."""
+    with stats_lock:
+        active = controller_stats["active_decoy_sets"]
+        for short_id, info in list(active.items()):
+            if not info.get("dry_run"):
+                continue
+            created_at = datetime.fromisoformat(info["created_at"])
+            if (now - created_at).total_seconds() / 60.0 > DEFAULT_TTL_MINUTES:
+                active.pop(short_id)
+                controller_stats["total_cleaned_sets"] += 1
+                root_logger.info(f"[dry-run] TTL expired for planned set {short_id}")
+
+
 def _ttl_cleanup():
     """
     Check all decoy pods for TTL expiry and delete expired sets.
@@ -602,6 +1228,10 @@ def _ttl_cleanup():
     while True:
         time.sleep(TTL_CHECK_INTERVAL)
         try:
+            # Dry-run sets have no pods carrying a TTL annotation
+            if DRY_RUN:
+                _expire_dry_run_sets(datetime.now(timezone.utc))
+
             k8s = get_k8s_client()
             if k8s is None:
                 continue
@@ -612,7 +1242,7 @@ def _ttl_cleanup():
             )
 
             now = datetime.now(timezone.utc)
-            expired_sets = set()
+            expired_sets = {}  # attack_id_short -> attacker_ip
 
             for pod in pods.items:
                 annotations = pod.metadata.annotations or {}
@@ -629,14 +1259,17 @@ def _ttl_cleanup():
                     age_minutes = (now - created_at).total_seconds() / 60.0
 
                     if age_minutes > ttl_minutes:
-                        expired_sets.add(attack_id_short)
+                        expired_sets[attack_id_short] = annotations.get(
+                            "deception-system/attacker-ip"
+                        )
                 except (ValueError, TypeError):
                     continue
 
             # Delete each expired set
-            for attack_id_short in expired_sets:
+            for attack_id_short, attacker_ip in expired_sets.items():
                 root_logger.info(
-                    f"TTL expired for attack set {attack_id_short}, cleaning up"
+                    f"TTL expired for attack set {attack_id_short}, cleaning up",
+                    extra={"fields": {"attack_id": attack_id_short}},
                 )
                 deleted = _delete_decoy_set(attack_id_short)
 
@@ -647,14 +1280,14 @@ def _ttl_cleanup():
                         "timestamp": datetime.now(timezone.utc).isoformat(),
                         "type": "decoy_expired",
                         "attack_id": attack_id_short,
+                        "attacker_ip": attacker_ip,
                         "resources_deleted": deleted,
                         "reason": "ttl_expired",
                     },
                 )
 
                 # Notify traffic-router to remove routing rule
-                publish_event(
-                    CH_ROUTING_UPDATE,
+                publish_route_update(
                     {
                         "timestamp": datetime.now(timezone.utc).isoformat(),
                         "type": "remove_route",
@@ -670,6 +1303,269 @@ def _ttl_cleanup():
             root_logger.error(f"TTL cleanup error: {e}")
 
 
+# ============================================================================
+# Decoy pod watcher
+# ============================================================================
+
+
+def _decoy_pod_problem(pod):
+    """Why a decoy pod is dead (e.g. "CrashLoopBackOff"), or None if it is fine."""
+    status = pod.status
+    if status is None:
+        return None
+    if status.phase == "Failed":
+        return status.reason or "Failed"
+    for cs in status.container_statuses or []:
+        state = cs.state
+        if state and state.waiting and state.waiting.reason in UNHEALTHY_WAITING_REASONS:
+            return state.waiting.reason
+        if (
+            state
+            and state.terminated
+            and state.terminated.reason in UNHEALTHY_TERMINATED_REASONS
+        ):
+            return state.terminated.reason
+    return None
+
+
+def _handle_decoy_pod_event(event_type, pod):
+    """
+    Track one decoy pod's health from a watch event and publish
+    decoy_unhealthy when it goes from healthy (or unseen) to dead.
+    """
+    name = pod.metadata.name
+    labels = pod.metadata.labels or {}
+    attack_id_short = labels.get("attack-id", "")
+
+    # Deleted or being deleted (TTL cleanup, eviction, unblock): not a failure
+    if event_type == "DELETED" or pod.metadata.deletion_timestamp:
+        with stats_lock:
+            decoy_pod_health.pop(name, None)
+            info = controller_stats["active_decoy_sets"].get(attack_id_short)
+            if info:
+                info.get("pod_health", {}).pop(name, None)
+        return
+
+    problem = _decoy_pod_problem(pod)
+    health = problem or "healthy"
+    with stats_lock:
+        previous = decoy_pod_health.get(name)
+        decoy_pod_health[name] = health
+        info = controller_stats["active_decoy_sets"].get(attack_id_short)
+        if info is not None:
+            info.setdefault("pod_health", {})[name] = health
+    # Only the healthy -> dead transition is broadcast; a dead pod moving
+    # between reasons (Error -> CrashLoopBackOff) just updates its status
+    if problem is None or previous not in (None, "healthy"):
+        return
+
+    restarts = sum(
+        cs.restart_count or 0 for cs in (pod.status.container_statuses or [])
+    )
+    attacker_ip = (pod.metadata.annotations or {}).get("deception-system/attacker-ip")
+    root_logger.warning(
+        f"Decoy pod {name} is unhealthy: {problem} (restarts={restarts})",
+        extra={
+            "fields": {
+                "attack_id": attack_id_short,
+                "pod": name,
+                "reason": problem,
+                "restarts": restarts,
+            }
+        },
+    )
+    publish_event(
+        CH_DECOY_SPAWNED,
+        {
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "type": "decoy_unhealthy",
+            "attack_id": attack_id_short,
+            "attacker_ip": attacker_ip,
+            "decoy_pod": name,
+            "decoy_type": labels.get("decoy-type", ""),
+            "reason": problem,
+            "restarts": restarts,
+        },
+    )
+
+    # Off the watch thread: recreating waits for the old pod to go away
+    threading.Thread(
+        target=_recreate_decoy_pod,
+        args=(attack_id_short, pod, problem),
+        daemon=True,
+        name=f"recreate-{name}",
+    ).start()
+
+
+def _wait_for_pod_deleted(k8s, name, timeout=60):
+    """Poll until a pod is gone. Returns True once it is, False on timeout."""
+    deadline = time.monotonic() + timeout
+    while time.monotonic() < deadline:
+        try:
+            k8s.read_namespaced_pod(name=name, namespace=DECOY_NAMESPACE)
+        except ApiException as e:
+            if e.status == 404:
+                return True
+        time.sleep(1)
+    return False
+
+
+def _recreate_decoy_pod(attack_id_short, pod, reason):
+    """
+    Replace a dead decoy pod with a fresh one of the same name and type.
+
+    Each set gets DECOY_MAX_RECREATES replacements; after that it is marked
+    degraded and left as is until TTL cleanup. The replacement keeps the
+    original created-at, so the set's TTL is not extended.
+    """
+    name = pod.metadata.name
+    with stats_lock:
+        info = controller_stats["active_decoy_sets"].get(attack_id_short)
+        if info is None:
+            # Not tracked (e.g. controller restarted): no template inputs
+            return
+        if info.get("recreates", 0) >= DECOY_MAX_RECREATES:
+            already_degraded = info.get("degraded", False)
+            info["degraded"] = True
+        else:
+            already_degraded = None
+            info["recreates"] = info.get("recreates", 0) + 1
+        recreates = info.get("recreates", 0)
+        attack_id = info["attack_id"]
+        attacker_ip = info["attacker_ip"]
+        attack_type = info["attack_type"]
+
+    if already_degraded is not None:
+        if not already_degraded:
+            root_logger.warning(
+                f"Decoy set {attack_id_short} degraded: {name} is {reason} and "
+                f"the recreate limit ({DECOY_MAX_RECREATES}) is used up",
+                extra={"fields": {"attack_id": attack_id_short, "pod": name}},
+            )
+            publish_event(
+                CH_DECOY_SPAWNED,
+                {
+                    "timestamp": datetime.now(timezone.utc).isoformat(),
+                    "type": "decoy_degraded",
+                    "attack_id": attack_id_short,
+                    "attacker_ip": attacker_ip,
+                    "decoy_pod": name,
+                    "reason": reason,
+                    "recreates": recreates,
+                },
+            )
+        return
+
+    annotations = pod.metadata.annotations or {}
+    try:
+        ttl_minutes = int(annotations.get("deception-system/ttl-minutes", ""))
+    except ValueError:
+        ttl_minutes = None
+    manifest = next(
+        (
+            r
+            for r in create_decoy_set(
+                attack_id, attacker_ip, attack_type, ttl_minutes=ttl_minutes
+            )
+            if r["kind"] == "Pod" and r["metadata"]["name"] == name
+        ),
+        None,
+    )
+    if manifest is None:
+        root_logger.error(f"No template for decoy pod {name}, not recreating")
+        return
+    created_at = annotations.get("deception-system/created-at")
+    if created_at:
+        manifest["metadata"]["annotations"]["deception-system/created-at"] = created_at
+
+    k8s = get_k8s_client()
+    if k8s is None:
+        return
+    try:
+        k8s.delete_namespaced_pod(
+            name=name, namespace=DECOY_NAMESPACE, grace_period_seconds=0
+        )
+    except ApiException as e:
+        if e.status != 404:
+            root_logger.error(f"Failed to delete dead decoy pod {name}: {e.status}")
+            return
+    if not _wait_for_pod_deleted(k8s, name):
+        root_logger.error(f"Dead decoy pod {name} did not go away, not recreating")
+        return
+
+    # The set may have been cleaned up while the old pod was going away
+    with stats_lock:
+        if attack_id_short not in controller_stats["active_decoy_sets"]:
+            return
+    error = _create_resource(k8s, manifest)
+    if error is not None:
+        root_logger.error(
+            f"Failed to recreate decoy pod {name}: {error.status} {error.reason}"
+        )
+        return
+
+    root_logger.info(
+        f"Recreated decoy pod {name} ({recreates}/{DECOY_MAX_RECREATES}) after {reason}",
+        extra={
+            "fields": {
+                "attack_id": attack_id_short,
+                "pod": name,
+                "recreates": recreates,
+            }
+        },
+    )
+    publish_event(
+        CH_DECOY_SPAWNED,
+        {
+            "timestamp": datetime.now(timezone.utc).isoformat(),
+            "type": "decoy_recreated",
+            "attack_id": attack_id_short,
+            "attacker_ip": attacker_ip,
+            "decoy_pod": name,
+            "reason": reason,
+            "recreates": recreates,
+        },
+    )
+
+
+def _decoy_pod_watcher():
+    """
+    Watch decoy pods and keep their health current, so crashed or
+    OOMKilled decoys don't keep showing as live.
+
+    Re-establishes the watch every POD_WATCH_TIMEOUT seconds or on error;
+    the initial ADDED events on each reconnect resync the health map.
+    """
+    while True:
+        k8s = get_k8s_client()
+        if k8s is None:
+            time.sleep(5)
+            continue
+
+        pod_watch = watch.Watch()
+        try:
+            for event in pod_watch.stream(
+                k8s.list_namespaced_pod,
+                namespace=DECOY_NAMESPACE,
+                label_selector="role=decoy",
+                timeout_seconds=POD_WATCH_TIMEOUT,
+            ):
+                try:
+                    _handle_decoy_pod_event(event["type"], event["object"])
+                except Exception as e:
+                    root_logger.error(f"Error handling decoy pod event: {e}")
+        except ApiException as e:
+            root_logger.warning(
+                f"Decoy pod watch API error: {e.status} {e.reason}; retrying in 5s"
+            )
+            time.sleep(5)
+        except Exception as e:
+            root_logger.error(f"Decoy pod watch error: {e}; retrying in 5s")
+            time.sleep(5)
+        finally:
+            pod_watch.stop()
+
+
 # ============================================================================
 # Redis subscriber loop
 # ============================================================================
@@ -744,6 +1640,34 @@ def after_request(response):
     return response
 
 
+@app.after_request
+def add_cors_headers(response):
+    """
+    Allow listed browser origins. Flask answers OPTIONS preflights itself
+    (without running views or the token check), so this covers them too.
+    """
+    origin = request.headers.get("Origin")
+    if not origin or not (
+        "*" in CORS_ALLOWED_ORIGINS or origin in CORS_ALLOWED_ORIGINS
+    ):
+        return response
+    response.headers["Access-Control-Allow-Origin"] = origin
+    response.headers.add("Vary", "Origin")
+    if request.method == "OPTIONS":
+        response.headers["Access-Control-Allow-Methods"] = "GET, POST, OPTIONS"
+        response.headers["Access-Control-Allow-Headers"] = "Authorization, Content-Type"
+        response.headers["Access-Control-Max-Age"] = "600"
+    return response
+
+
+@app.errorhandler(413)
+def request_too_large(_error):
+    return (
+        jsonify({"error": "Request body too large", "max_bytes": MAX_BODY_BYTES}),
+        413,
+    )
+
+
 # ---------------------------------------------------------------------------
 # GET /status — Current controller state
 # ---------------------------------------------------------------------------
@@ -751,6 +1675,7 @@ def after_request(response):
 def status():
     """Return current decoy controller state."""
     pod_count = _get_decoy_pod_count()
+    now = time.monotonic()
     with stats_lock:
         return jsonify(
             {
@@ -758,13 +1683,37 @@ def status():
                 "total_spawned_sets": controller_stats["total_spawned_sets"],
                 "total_cleaned_sets": controller_stats["total_cleaned_sets"],
                 "total_duplicate_skipped": controller_stats["total_duplicate_skipped"],
+                "total_invalid_skipped": controller_stats["total_invalid_skipped"],
                 "total_evictions": controller_stats["total_evictions"],
+                "total_throttled": controller_stats["total_throttled"],
+                "total_low_confidence_skipped": controller_stats[
+                    "total_low_confidence_skipped"
+                ],
+                "total_deduplicated": controller_stats["total_deduplicated"],
+                "total_auto_deploy_skipped": controller_stats[
+                    "total_auto_deploy_skipped"
+                ],
+                "total_spawn_failures": controller_stats["total_spawn_failures"],
+                "total_backoff_skipped": controller_stats["total_backoff_skipped"],
+                "spawn_backoff": {
+                    ip: {
+                        "failures": entry["failures"],
+                        "retry_in_seconds": round(entry["retry_at"] - now, 1),
+                    }
+                    for ip, entry in spawn_failures.items()
+                    if entry["retry_at"] > now
+                },
+                "alert_dedup_seconds": ALERT_DEDUP_SECONDS,
+                "auto_deploy": AUTO_DEPLOY,
+                "min_decoy_confidence": MIN_DECOY_CONFIDENCE,
+                "capacity_policy": CAPACITY_POLICY,
                 "active_decoy_sets": controller_stats["active_decoy_sets"],
                 "active_set_count": len(controller_stats["active_decoy_sets"]),
                 "current_pod_count": pod_count,
                 "max_pods": MAX_DECOY_PODS,
                 "max_sets": MAX_DECOY_SETS,
                 "decoy_namespace": DECOY_NAMESPACE,
+                "dry_run": DRY_RUN,
                 "started_at": controller_stats["started_at"],
                 "uptime_seconds": round(
                     (
@@ -776,6 +1725,300 @@ def status():
         )
 
 
+# Fails closed: 503 for every call while CONTROLLER_API_TOKEN is unset
+require_token = require_bearer_token(CONTROLLER_API_TOKEN, "CONTROLLER_API_TOKEN")
+
+
+# ---------------------------------------------------------------------------
+# POST /api/deploy — Spawn decoys for an operator-chosen IP
+# ---------------------------------------------------------------------------
+@app.route("/api/deploy", methods=["POST"])
+@require_token
+def deploy():
+    """
+    Spawn a decoy set for an IP without waiting for an analyzer alert.
+
+    Expects JSON body:
+    {
+        "source_ip": "203.0.113.7",
+        "attack_type": "manual",     # optional, picks the decoy profile
+        "ttl_minutes": 30            # optional, default DECOY_TTL_MINUTES
+    }
+
+    The request becomes a synthetic attack event (confidence 1.0, so
+    MIN_DECOY_CONFIDENCE never filters it, and severity critical) handled exactly like one from
+    Redis, on a background thread since pods take a while to become Ready.
+    Returns 202 with the attack id, or 409 if the IP already has decoys.
+    """
+    data = request.get_json(silent=True)
+    if not isinstance(data, dict):
+        return jsonify({"error": "Request body must be a JSON object"}), 400
+
+    event = {
+        "timestamp": datetime.now(timezone.utc).isoformat(),
+        "type": "attack_detected",
+        "attack_id": str(uuid.uuid4()),
+        "source_ip": data.get("source_ip"),
+        "attack_type": str(data.get("attack_type") or "manual"),
+        "confidence": 1.0,
+        "severity": "critical",
+        "evidence": "manual deploy",
+        "source": "manual",
+    }
+    error = _validate_attack_event(event)
+    if error:
+        return jsonify({"error": error}), 400
+
+    if data.get("ttl_minutes") is not None:
+        try:
+            ttl_minutes = int(data["ttl_minutes"])
+        except (TypeError, ValueError):
+            return jsonify({"error": "ttl_minutes must be an integer"}), 400
+        if not 1 <= ttl_minutes <= MAX_MANUAL_TTL_MINUTES:
+            return (
+                jsonify(
+                    {"error": f"ttl_minutes must be 1-{MAX_MANUAL_TTL_MINUTES}"}
+                ),
+                400,
+            )
+        event["ttl_minutes"] = ttl_minutes
+
+    source_ip = event["source_ip"]
+    if not DRY_RUN and _has_existing_decoys_for_ip(source_ip):
+        return (
+            jsonify(
+                {
+                    "error": "decoys already exist for this IP",
+                    "attack_id": _get_existing_attack_short_for_ip(source_ip),
+                }
+            ),
+            409,
+        )
+
+    root_logger.info(
+        f"Manual deploy requested for {source_ip}",
+        extra={
+            "fields": {
+                "attack_id": event["attack_id"][:8],
+                "source_ip": source_ip,
+                "attack_type": event["attack_type"],
+            }
+        },
+    )
+    threading.Thread(
+        target=handle_attack_event, args=(event,), name="manual-deploy", daemon=True
+    ).start()
+
+    short_id = event["attack_id"][:8]
+    return (
+        jsonify(
+            {
+                "status": "accepted",
+                "attack_id": event["attack_id"],
+                "decoy_set": short_id,
+                "frontend_service": f"decoy-fe-{short_id}.{DECOY_NAMESPACE}.svc.cluster.local:3000",
+                "dry_run": DRY_RUN,
+            }
+        ),
+        202,
+    )
+
+
+# ---------------------------------------------------------------------------
+# POST /api/unblock — Tear down decoys and routing for an IP
+# ---------------------------------------------------------------------------
+@app.route("/api/unblock", methods=["POST"])
+@require_token
+def unblock():
+    """
+    Release a (possibly wrongly flagged) IP: delete its decoy sets and tell
+    the traffic-router to send it back to the real frontend.
+
+    Expects JSON body: {"source_ip": "203.0.113.7"}
+
+    The route removal is published even when no sets are found, so a stale
+    route left behind by a missed event is cleared too. A later alert for
+    the IP will spawn decoys again as usual.
+
+    This lets an attacker out of containment, so it is disabled (503) until
+    CONTROLLER_API_TOKEN is set, and every call is logged with the caller.
+    """
+    data = request.get_json(silent=True)
+    if not isinstance(data, dict):
+        return jsonify({"error": "Request body must be a JSON object"}), 400
+    error = _validate_attack_event(data)
+    if error:
+        return jsonify({"error": error}), 400
+    source_ip = data["source_ip"]
+
+    short_ids = sorted(_get_attack_sets_for_ip(source_ip))
+    resources_deleted = 0
+    for short_id in short_ids:
+        if DRY_RUN:
+            with stats_lock:
+                controller_stats["active_decoy_sets"].pop(short_id, None)
+            deleted = 0
+        else:
+            deleted = _delete_decoy_set(short_id)
+        resources_deleted += deleted
+        publish_event(
+            CH_DECOY_SPAWNED,
+            {
+                "timestamp": datetime.now(timezone.utc).isoformat(),
+                "type": "decoy_removed",
+                "attack_id": short_id,
+                "attacker_ip": source_ip,
+                "resources_deleted": deleted,
+                "reason": "manual_unblock",
+            },
+        )
+        with stats_lock:
+            controller_stats["total_cleaned_sets"] += 1
+
+    if not DRY_RUN:
+        publish_route_update(
+            {
+                "timestamp": datetime.now(timezone.utc).isoformat(),
+                "type": "remove_route",
+                "attacker_ip": source_ip,
+                "reason": "manual_unblock",
+            },
+        )
+
+    root_logger.info(
+        f"Unblocked {source_ip}: {len(short_ids)} decoy sets removed",
+        extra={
+            "fields": {
+                "source_ip": source_ip,
+                "attack_ids": short_ids,
+                "requested_by": request.remote_addr,
+            }
+        },
+    )
+    return jsonify(
+        {
+            "status": "ok",
+            "source_ip": source_ip,
+            "sets_removed": len(short_ids),
+            "attack_ids": short_ids,
+            "resources_deleted": resources_deleted,
+            "dry_run": DRY_RUN,
+        }
+    )
+
+
+# ---------------------------------------------------------------------------
+# GET /api/stats — Server-side aggregates for the dashboard
+# ---------------------------------------------------------------------------
+@app.route("/api/stats")
+def get_stats():
+    """
+    Summarise controller state so the dashboard survives a page refresh.
+
+    Active figures come from the tracked decoy sets; alert counts are
+    cumulative since controller start (valid alerts only).
+    """
+    with stats_lock:
+        active_sets = list(controller_stats["active_decoy_sets"].values())
+        payload = {
+            "total_attacks_received": controller_stats["total_attacks_received"],
+            "total_spawned_sets": controller_stats["total_spawned_sets"],
+            "total_cleaned_sets": controller_stats["total_cleaned_sets"],
+            "alerts_by_type": dict(controller_stats["alerts_by_type"]),
+            "alerts_by_severity": {
+                level: controller_stats["alerts_by_severity"].get(level, 0)
+                for level in SEVERITY_LEVELS
+            },
+        }
+
+    active_by_type = defaultdict(int)
+    for info in active_sets:
+        active_by_type[info.get("attack_type", "unknown")] += 1
+
+    payload.update(
+        {
+            "active_set_count": len(active_sets),
+            "active_pod_count": sum(len(info.get("pods", [])) for info in active_sets),
+            "active_attacker_ips": sorted(
+                {info.get("attacker_ip") for info in active_sets if info.get("attacker_ip")}
+            ),
+            "active_sets_by_type": dict(active_by_type),
+            "max_sets": MAX_DECOY_SETS,
+            "dry_run": DRY_RUN,
+            "auto_deploy": AUTO_DEPLOY,
+            "generated_at": datetime.now(timezone.utc).isoformat(),
+        }
+    )
+    payload["active_attacker_count"] = len(payload["active_attacker_ips"])
+    return jsonify(payload)
+
+
+# ---------------------------------------------------------------------------
+# GET /api/timeline — Chronological alert/decoy/cleanup history
+# ---------------------------------------------------------------------------
+def _parse_time(value):
+    """Parse an ISO-8601 timestamp, treating naive values as UTC."""
+    parsed = datetime.fromisoformat(value.replace("Z", "+00:00"))
+    if parsed.tzinfo is None:
+        parsed = parsed.replace(tzinfo=timezone.utc)
+    return parsed
+
+
+@app.route("/api/timeline")
+def get_timeline():
+    """
+    Return recorded events oldest-first, optionally filtered.
+
+    Query params: source_ip, since, until (ISO-8601). Events that carry only
+    an attack_id (e.g. remove_route) are kept when the IP's alerts or decoys
+    share that attack_id.
+    """
+    source_ip = request.args.get("source_ip", "").strip()
+    try:
+        since = _parse_time(request.args["since"]) if request.args.get("since") else None
+        until = _parse_time(request.args["until"]) if request.args.get("until") else None
+    except ValueError:
+        return jsonify({"error": "since/until must be ISO-8601 timestamps"}), 400
+
+    with timeline_lock:
+        events = list(timeline)
+
+    if source_ip:
+        attack_ids = {
+            str(e["attack_id"])[:8]
+            for e in events
+            if e.get("attacker_ip") == source_ip and e.get("attack_id")
+        }
+        events = [
+            e
+            for e in events
+            if e.get("attacker_ip") == source_ip
+            or (
+                not e.get("attacker_ip")
+                and str(e.get("attack_id", ""))[:8] in attack_ids
+            )
+        ]
+
+    selected = []
+    for e in events:
+        try:
+            ts = _parse_time(e.get("timestamp", ""))
+        except ValueError:
+            continue
+        if (since and ts < since) or (until and ts > until):
+            continue
+        selected.append((ts, e))
+    selected.sort(key=lambda item: item[0])
+
+    return jsonify(
+        {
+            "source_ip": source_ip or None,
+            "count": len(selected),
+            "events": [e for _, e in selected],
+        }
+    )
+
+
 # ---------------------------------------------------------------------------
 # GET /health — Health check
 # ---------------------------------------------------------------------------
@@ -802,13 +2045,70 @@ def health():
     )
 
 
+# ---------------------------------------------------------------------------
+# GET /ready — Readiness check (downstream dependencies)
+# ---------------------------------------------------------------------------
+def _ready_check(check):
+    """Run one readiness check: {"ok": True} or {"ok": False, "error": ...}."""
+    try:
+        check()
+        return {"ok": True}
+    except Exception as e:
+        return {"ok": False, "error": str(e).strip()[:200]}
+
+
+def _ping_redis():
+    """Ping Redis on a fresh connection, bounded by READY_CHECK_TIMEOUT."""
+    client = redis.from_url(
+        REDIS_URL,
+        socket_connect_timeout=READY_CHECK_TIMEOUT,
+        socket_timeout=READY_CHECK_TIMEOUT,
+    )
+    try:
+        client.ping()
+    finally:
+        client.close()
+
+
+def _list_decoy_pods():
+    """One-item pod list in DECOY_NAMESPACE: API reachable and RBAC in place."""
+    k8s = get_k8s_client()
+    if k8s is None:
+        raise RuntimeError("Kubernetes config unavailable")
+    k8s.list_namespaced_pod(
+        namespace=DECOY_NAMESPACE, limit=1, _request_timeout=READY_CHECK_TIMEOUT
+    )
+
+
+@app.route("/ready")
+def ready():
+    """
+    Readiness: 503 unless Redis (alerts in, routes out) and, outside
+    DRY_RUN, the Kubernetes API answer. /health stays the liveness check.
+    """
+    checks = {"redis": _ready_check(_ping_redis)}
+    if not DRY_RUN:
+        checks["kubernetes"] = _ready_check(_list_decoy_pods)
+    ok = all(check["ok"] for check in checks.values())
+    return (
+        jsonify(
+            {
+                "status": "ready" if ok else "not_ready",
+                "service": "deception-controller",
+                "checks": checks,
+            }
+        ),
+        200 if ok else 503,
+    )
+
+
 # ============================================================================
 # Startup: launch background threads
 # ============================================================================
 
 
 def start_background_threads():
-    """Launch the Redis subscriber and TTL cleanup threads."""
+    """Launch the Redis subscriber, TTL cleanup and decoy pod watcher threads."""
     subscriber_thread = threading.Thread(
         target=_redis_subscriber_loop,
         daemon=True,
@@ -825,6 +2125,16 @@ def start_background_threads():
     ttl_thread.start()
     root_logger.info("TTL cleanup thread started")
 
+    # Dry-run creates no pods, so there is nothing to watch
+    if not DRY_RUN:
+        watcher_thread = threading.Thread(
+            target=_decoy_pod_watcher,
+            daemon=True,
+            name="decoy-pod-watcher",
+        )
+        watcher_thread.start()
+        root_logger.info("Decoy pod watcher thread started")
+
 
 # Start background threads at import time (gunicorn --preload will call this
 # once in the master process, and the daemon threads will be inherited by
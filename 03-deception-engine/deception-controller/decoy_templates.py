@@ -10,7 +10,9 @@ All resources are created in the decoy-pool namespace with labels that
 tie them to a specific attack event, enabling bulk cleanup by attack-id.
 """
 
+import json
 import os
+import re
 from datetime import datetime, timezone
 
 # ---------------------------------------------------------------------------
@@ -22,13 +24,247 @@ REDIS_URL = os.environ.get(
 )
 DEFAULT_TTL_MINUTES = int(os.environ.get("DECOY_TTL_MINUTES", "10"))
 
+# Images default to the locally built ones imported by build-images.sh;
+# override to pull from a registry (empty values fall back to the default)
+DECOY_FRONTEND_IMAGE = (
+    os.environ.get("DECOY_FRONTEND_IMAGE") or "deception/decoy-frontend:latest"
+)
+DECOY_API_IMAGE = os.environ.get("DECOY_API_IMAGE") or "deception/decoy-api:latest"
+DECOY_DB_IMAGE = os.environ.get("DECOY_DB_IMAGE") or "deception/decoy-db:latest"
+# Any case of a Kubernetes pull policy; anything else falls back to "Never"
+# (the controller logs it at startup) instead of a 422 on every spawn
+_PULL_POLICIES = {p.lower(): p for p in ("Always", "IfNotPresent", "Never")}
+_pull_policy = os.environ.get("DECOY_IMAGE_PULL_POLICY") or "Never"
+DECOY_IMAGE_PULL_POLICY = _PULL_POLICIES.get(_pull_policy.lower(), "Never")
+INVALID_IMAGE_PULL_POLICY = (
+    None if _pull_policy.lower() in _PULL_POLICIES else _pull_policy
+)
+
+# Per-type container resources, overridable with
+# DECOY_<FRONTEND|API|DB>_<CPU|MEM>_<REQUEST|LIMIT> (Kubernetes quantities).
+# Keep the totals for 5 sets inside the decoy-pool ResourceQuota.
+_QUANTITY_RE = re.compile(r"^[0-9]+(\.[0-9]+)?(m|k|M|G|T|Ki|Mi|Gi|Ti)?$")
+_RESOURCE_DEFAULTS = {
+    "FRONTEND": {"cpu": ("25m", "50m"), "memory": ("32Mi", "96Mi")},
+    "API": {"cpu": ("25m", "50m"), "memory": ("32Mi", "96Mi")},
+    "DB": {"cpu": ("50m", "100m"), "memory": ("48Mi", "64Mi")},
+}
+
+
+def _load_decoy_resources():
+    """
+    Resolve per-type requests/limits from the environment.
+
+    Raises ValueError on a malformed quantity so a typo stops the controller
+    at startup instead of failing every pod creation later.
+    """
+    resources = {}
+    for decoy_type, defaults in _RESOURCE_DEFAULTS.items():
+        requests, limits = {}, {}
+        for name, env_name in (("cpu", "CPU"), ("memory", "MEM")):
+            request_default, limit_default = defaults[name]
+            for target, kind, default in (
+                (requests, "REQUEST", request_default),
+                (limits, "LIMIT", limit_default),
+            ):
+                var = f"DECOY_{decoy_type}_{env_name}_{kind}"
+                value = os.environ.get(var) or default
+                if not _QUANTITY_RE.match(value):
+                    raise ValueError(f"{var}={value!r} is not a valid quantity")
+                target[name] = value
+        resources[decoy_type] = {"requests": requests, "limits": limits}
+    return resources
+
+
+DECOY_RESOURCES = _load_decoy_resources()
+
+# Passed to decoy frontends: fake login bypass / reflected XSS bait
+DECOY_VULN_MODE = os.environ.get("DECOY_VULN_MODE", "false").lower() in (
+    "1",
+    "true",
+    "yes",
+)
+# Passed to decoy frontends: 5xx probability (0.0-1.0) and latency jitter
+DECOY_ERROR_RATE = os.environ.get("DECOY_ERROR_RATE", "0")
+DECOY_JITTER_MS = os.environ.get("DECOY_JITTER_MS", "400")
+# Passed to decoy frontends: checkout decline probability and fraud cutoff
+DECOY_DECLINE_RATE = os.environ.get("DECOY_DECLINE_RATE", "0")
+DECOY_FRAUD_THRESHOLD = os.environ.get("DECOY_FRAUD_THRESHOLD", "0")
+# Passed to decoy frontends: render product/cart APIs as HTML for browsers
+DECOY_CONTENT_NEGOTIATION = os.environ.get(
+    "DECOY_CONTENT_NEGOTIATION", "true"
+).lower() not in ("0", "false", "no")
+# Passed to decoy frontends: salt for the per-decoy honeytoken API key
+DECOY_HONEYTOKEN_SALT = os.environ.get("DECOY_HONEYTOKEN_SALT", "")
+
+# Decoys face attackers directly, so their containers are locked down:
+# non-root, read-only root filesystem, no capabilities, no privilege
+# escalation. Each can be relaxed for an image that needs it.
+def _env_flag(name, default):
+    value = os.environ.get(name, default).lower()
+    return value not in ("0", "false", "no")
+
+
+DECOY_RUN_AS_NON_ROOT = _env_flag("DECOY_RUN_AS_NON_ROOT", "true")
+DECOY_READ_ONLY_ROOT_FS = _env_flag("DECOY_READ_ONLY_ROOT_FS", "true")
+DECOY_DROP_CAPABILITIES = _env_flag("DECOY_DROP_CAPABILITIES", "true")
+DECOY_ALLOW_PRIVILEGE_ESCALATION = _env_flag(
+    "DECOY_ALLOW_PRIVILEGE_ESCALATION", "false"
+)
+# UID each image runs as (node:18-alpine "node", postgres:16-alpine
+# "postgres"); numeric so the kubelet can verify runAsNonRoot
+_DECOY_RUN_AS_USER = {"frontend": 1000, "api": 1000, "database": 70}
+# Paths each image writes to, mounted as emptyDirs so the root filesystem
+# can stay read-only
+_DECOY_WRITABLE_PATHS = {
+    "database": ["/var/lib/postgresql/data", "/var/run/postgresql", "/tmp"],
+}
+
+# Preferred pod anti-affinity between the pods of one decoy set, so a
+# single node failure doesn't take the whole set down. Only a scheduling
+# preference; disable it to skip the extra scheduler work on one node.
+DECOY_ANTI_AFFINITY = _env_flag("DECOY_ANTI_AFFINITY", "true")
+
+# Pin decoys to dedicated (e.g. tainted "quarantine") nodes.
+# DECOY_NODE_SELECTOR is comma-separated key=value labels; DECOY_TOLERATIONS
+# is a JSON list of pod tolerations, e.g.
+# [{"key": "quarantine", "operator": "Exists", "effect": "NoSchedule"}]
+_LABEL_NAME_RE = re.compile(r"^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$")
+_LABEL_PREFIX_RE = re.compile(r"^[a-z0-9]([-a-z0-9.]{0,251}[a-z0-9])?$")
+_TOLERATION_FIELDS = {"key", "operator", "value", "effect", "tolerationSeconds"}
+_TOLERATION_OPERATORS = {"Exists", "Equal"}
+_TOLERATION_EFFECTS = {"", "NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+
+def _load_node_selector():
+    """
+    Parse DECOY_NODE_SELECTOR into a nodeSelector dict.
+
+    Raises ValueError on a malformed pair or label so a typo stops the
+    controller at startup instead of leaving every decoy Pending.
+    """
+    selector = {}
+    raw = os.environ.get("DECOY_NODE_SELECTOR", "")
+    for pair in filter(None, (p.strip() for p in raw.split(","))):
+        key, sep, value = pair.partition("=")
+        key, value = key.strip(), value.strip()
+        prefix, _, name = key.rpartition("/")
+        if (
+            not sep
+            or not _LABEL_NAME_RE.match(name)
+            or (prefix and not _LABEL_PREFIX_RE.match(prefix))
+            or (value and not _LABEL_NAME_RE.match(value))
+        ):
+            raise ValueError(f"DECOY_NODE_SELECTOR: invalid label pair {pair!r}")
+        selector[key] = value
+    return selector
+
+
+def _load_tolerations():
+    """Parse and validate DECOY_TOLERATIONS. Raises ValueError if malformed."""
+    raw = os.environ.get("DECOY_TOLERATIONS", "").strip()
+    if not raw:
+        return []
+    try:
+        tolerations = json.loads(raw)
+    except ValueError as e:
+        raise ValueError(f"DECOY_TOLERATIONS is not valid JSON: {e}") from e
+    if not isinstance(tolerations, list):
+        raise ValueError("DECOY_TOLERATIONS must be a JSON list of tolerations")
+    for i, toleration in enumerate(tolerations):
+        if not isinstance(toleration, dict):
+            raise ValueError(f"DECOY_TOLERATIONS[{i}] must be an object")
+        unknown = sorted(set(toleration) - _TOLERATION_FIELDS)
+        if unknown:
+            raise ValueError(f"DECOY_TOLERATIONS[{i}]: unknown fields {unknown}")
+        operator = toleration.get("operator", "Equal")
+        if operator not in _TOLERATION_OPERATORS:
+            raise ValueError(f"DECOY_TOLERATIONS[{i}]: invalid operator {operator!r}")
+        if toleration.get("effect", "") not in _TOLERATION_EFFECTS:
+            raise ValueError(
+                f"DECOY_TOLERATIONS[{i}]: invalid effect {toleration['effect']!r}"
+            )
+        if operator == "Exists" and toleration.get("value"):
+            raise ValueError(f"DECOY_TOLERATIONS[{i}]: Exists takes no value")
+        if operator == "Equal" and not toleration.get("key"):
+            raise ValueError(f"DECOY_TOLERATIONS[{i}]: Equal needs a key")
+    return tolerations
+
+
+DECOY_NODE_SELECTOR = _load_node_selector()
+DECOY_TOLERATIONS = _load_tolerations()
+
+# Decoy profiles: per-attack-type overrides of the frontend behaviour above,
+# so each set leans into what that attacker is after. DECOY_PROFILES (JSON,
+# {"<attack_type>": {"DECOY_JITTER_MS": "2000", ...}}) adds or replaces
+# profiles; attack types without one get "default" (no overrides).
+_PROFILE_SETTINGS = {
+    "DECOY_VULN_MODE",
+    "DECOY_ERROR_RATE",
+    "DECOY_JITTER_MS",
+    "DECOY_DECLINE_RATE",
+    "DECOY_FRAUD_THRESHOLD",
+    "DECOY_CONTENT_NEGOTIATION",
+}
+_BUILTIN_PROFILES = {
+    "default": {},
+    # Slow logins stretch out credential stuffing
+    "brute_force": {"DECOY_JITTER_MS": "1500"},
+    # Injection attempts "succeed", keeping the attacker probing the decoy
+    "sqli": {"DECOY_VULN_MODE": "true"},
+    "xss": {"DECOY_VULN_MODE": "true"},
+    # Scanners get a flaky-looking target that's worth a closer look
+    "recon_scanner": {"DECOY_ERROR_RATE": "0.05"},
+    "recon_scanning": {"DECOY_ERROR_RATE": "0.05"},
+}
+
+
+def _load_decoy_profiles():
+    """
+    Merge DECOY_PROFILES over the built-in profiles.
+
+    Raises ValueError on malformed JSON or an unknown setting, for the same
+    reason as _load_decoy_resources.
+    """
+    profiles = {name: dict(settings) for name, settings in _BUILTIN_PROFILES.items()}
+    raw = os.environ.get("DECOY_PROFILES", "").strip()
+    if not raw:
+        return profiles
+    try:
+        custom = json.loads(raw)
+    except json.JSONDecodeError as e:
+        raise ValueError(f"DECOY_PROFILES is not valid JSON: {e}") from e
+    if not isinstance(custom, dict):
+        raise ValueError("DECOY_PROFILES must be a JSON object")
+    for name, settings in custom.items():
+        if not isinstance(settings, dict):
+            raise ValueError(f"DECOY_PROFILES[{name!r}] must be an object")
+        unknown = set(settings) - _PROFILE_SETTINGS
+        if unknown:
+            raise ValueError(
+                f"DECOY_PROFILES[{name!r}] has unknown settings: {sorted(unknown)}"
+            )
+        profiles[name] = {
+            key: (str(value).lower() if isinstance(value, bool) else str(value))
+            for key, value in settings.items()
+        }
+    return profiles
+
+
+DECOY_PROFILES = _load_decoy_profiles()
+
+
+def profile_for(attack_type):
+    """Name of the decoy profile used for an attack type."""
+    return attack_type if attack_type in DECOY_PROFILES else "default"
+
 
 # ============================================================================
 # Public API
 # ============================================================================
 
 
-def create_decoy_set(attack_id, attacker_ip, attack_type):
+def create_decoy_set(attack_id, attacker_ip, attack_type, ttl_minutes=None):
     """
     Generate a complete decoy set (3 pods + 3 services) for an attack event.
 
@@ -40,6 +276,8 @@ def create_decoy_set(attack_id, attacker_ip, attack_type):
         Source IP of the attacker.
     attack_type : str
         Classification of the attack (e.g. "sqli", "xss", "recon_scanning").
+    ttl_minutes : int, optional
+        Lifetime before TTL cleanup; defaults to DECOY_TTL_MINUTES.
 
     Returns
     -------
@@ -50,9 +288,19 @@ def create_decoy_set(attack_id, attacker_ip, attack_type):
     short_id = attack_id[:8]
     now = datetime.now(timezone.utc).isoformat()
 
-    # Sanitise attacker_ip for use in label values (dots are allowed, colons
-    # from IPv6 are not — replace with dashes)
-    safe_ip = attacker_ip.replace(":", "-")
+    safe_ip = sanitize_ip_label(attacker_ip)
+
+    ttl_minutes = ttl_minutes or DEFAULT_TTL_MINUTES
+    profile = profile_for(attack_type)
+    frontend_settings = {
+        "DECOY_VULN_MODE": "true" if DECOY_VULN_MODE else "false",
+        "DECOY_ERROR_RATE": DECOY_ERROR_RATE,
+        "DECOY_JITTER_MS": DECOY_JITTER_MS,
+        "DECOY_DECLINE_RATE": DECOY_DECLINE_RATE,
+        "DECOY_FRAUD_THRESHOLD": DECOY_FRAUD_THRESHOLD,
+        "DECOY_CONTENT_NEGOTIATION": "true" if DECOY_CONTENT_NEGOTIATION else "false",
+        **DECOY_PROFILES[profile],
+    }
 
     resources = []
 
@@ -61,16 +309,22 @@ def create_decoy_set(attack_id, attacker_ip, attack_type):
     resources.append(
         _make_pod(
             name=fe_name,
-            image="deception/decoy-frontend:latest",
+            image=DECOY_FRONTEND_IMAGE,
             port=3000,
             attack_id=attack_id,
             attacker_ip=safe_ip,
             attack_type=attack_type,
             decoy_type="frontend",
             created_at=now,
-            resources_limits={"memory": "96Mi", "cpu": "50m"},
-            resources_requests={"memory": "32Mi", "cpu": "25m"},
-            env_extra=[],
+            profile=profile,
+            ttl_minutes=ttl_minutes,
+            resources_limits=DECOY_RESOURCES["FRONTEND"]["limits"],
+            resources_requests=DECOY_RESOURCES["FRONTEND"]["requests"],
+            env_extra=[
+                {"name": name, "value": value}
+                for name, value in frontend_settings.items()
+            ]
+            + [{"name": "DECOY_HONEYTOKEN_SALT", "value": DECOY_HONEYTOKEN_SALT}],
         )
     )
     resources.append(
@@ -88,15 +342,17 @@ def create_decoy_set(attack_id, attacker_ip, attack_type):
     resources.append(
         _make_pod(
             name=api_name,
-            image="deception/decoy-api:latest",
+            image=DECOY_API_IMAGE,
             port=8081,
             attack_id=attack_id,
             attacker_ip=safe_ip,
             attack_type=attack_type,
             decoy_type="api",
             created_at=now,
-            resources_limits={"memory": "96Mi", "cpu": "50m"},
-            resources_requests={"memory": "32Mi", "cpu": "25m"},
+            profile=profile,
+            ttl_minutes=ttl_minutes,
+            resources_limits=DECOY_RESOURCES["API"]["limits"],
+            resources_requests=DECOY_RESOURCES["API"]["requests"],
             env_extra=[],
         )
     )
@@ -115,20 +371,25 @@ def create_decoy_set(attack_id, attacker_ip, attack_type):
     resources.append(
         _make_pod(
             name=db_name,
-            image="deception/decoy-db:latest",
+            image=DECOY_DB_IMAGE,
             port=5432,
             attack_id=attack_id,
             attacker_ip=safe_ip,
             attack_type=attack_type,
             decoy_type="database",
             created_at=now,
-            # DB gets more resources — postgres overhead
-            resources_limits={"memory": "64Mi", "cpu": "100m"},
-            resources_requests={"memory": "48Mi", "cpu": "50m"},
+            profile=profile,
+            ttl_minutes=ttl_minutes,
+            # DB gets more CPU by default — postgres overhead
+            resources_limits=DECOY_RESOURCES["DB"]["limits"],
+            resources_requests=DECOY_RESOURCES["DB"]["requests"],
             env_extra=[
                 {"name": "POSTGRES_DB", "value": "ecommerce"},
                 {"name": "POSTGRES_USER", "value": "appuser"},
                 {"name": "POSTGRES_PASSWORD", "value": "d3c0y-Tr4p-2024"},
+                # A subdirectory of the emptyDir mount, so initdb (running as
+                # postgres) owns it
+                {"name": "PGDATA", "value": "/var/lib/postgresql/data/pgdata"},
             ],
         )
     )
@@ -145,6 +406,25 @@ def create_decoy_set(attack_id, attacker_ip, attack_type):
     return resources
 
 
+def sanitize_ip_label(attacker_ip):
+    """
+    Convert an attacker IP into a valid Kubernetes label value.
+
+    Dots are allowed in label values but colons from IPv6 are not, so they
+    are replaced with dashes. Label values must also start and end with an
+    alphanumeric character, which compressed IPv6 forms like "::1" or
+    "fe80::" violate — those are padded with the implied zero group so the
+    mapping stays one-to-one ("::1" -> "0--1", "fe80::" -> "fe80--0").
+    Scoped IPv6 ("fe80::1%eth0") is rejected before it gets here.
+    """
+    safe_ip = attacker_ip.replace(":", "-")
+    if safe_ip.startswith("-"):
+        safe_ip = "0" + safe_ip
+    if safe_ip.endswith("-"):
+        safe_ip = safe_ip + "0"
+    return safe_ip
+
+
 # ============================================================================
 # Private helpers — build K8s resource dicts
 # ============================================================================
@@ -159,6 +439,8 @@ def _make_pod(
     attack_type,
     decoy_type,
     created_at,
+    profile,
+    ttl_minutes,
     resources_limits,
     resources_requests,
     env_extra,
@@ -185,7 +467,8 @@ def _make_pod(
     annotations = {
         "deception-system/created-at": created_at,
         "deception-system/attack-type": attack_type,
-        "deception-system/ttl-minutes": str(DEFAULT_TTL_MINUTES),
+        "deception-system/decoy-profile": profile,
+        "deception-system/ttl-minutes": str(ttl_minutes),
         "deception-system/attack-id": attack_id,
         "deception-system/attacker-ip": attacker_ip,
     }
@@ -196,6 +479,8 @@ def _make_pod(
         {"name": "ATTACK_ID", "value": attack_id},
         {"name": "ATTACKER_IP", "value": attacker_ip},
         {"name": "REDIS_URL", "value": REDIS_URL},
+        # Lets decoy_interaction events name the pod the attacker is hitting
+        {"name": "POD_NAME", "valueFrom": {"fieldRef": {"fieldPath": "metadata.name"}}},
     ] + env_extra
 
     probe = None
@@ -223,23 +508,74 @@ def _make_pod(
             "failureThreshold": 6,
         }
 
+    security_context = {
+        "allowPrivilegeEscalation": DECOY_ALLOW_PRIVILEGE_ESCALATION,
+        "readOnlyRootFilesystem": DECOY_READ_ONLY_ROOT_FS,
+        "seccompProfile": {"type": "RuntimeDefault"},
+    }
+    if DECOY_RUN_AS_NON_ROOT:
+        security_context["runAsNonRoot"] = True
+        security_context["runAsUser"] = _DECOY_RUN_AS_USER[decoy_type]
+    if DECOY_DROP_CAPABILITIES:
+        security_context["capabilities"] = {"drop": ["ALL"]}
+
+    writable_paths = _DECOY_WRITABLE_PATHS.get(decoy_type, [])
+    volumes = [
+        {"name": f"writable-{i}", "emptyDir": {}} for i in range(len(writable_paths))
+    ]
+
     container_spec = {
         "name": name,
         "image": image,
-        "imagePullPolicy": "Never",
+        "imagePullPolicy": DECOY_IMAGE_PULL_POLICY,
         "ports": [{"containerPort": port, "protocol": "TCP"}],
         "env": env,
         "resources": {
             "requests": resources_requests,
             "limits": resources_limits,
         },
+        "securityContext": security_context,
     }
+    if writable_paths:
+        container_spec["volumeMounts"] = [
+            {"name": f"writable-{i}", "mountPath": path}
+            for i, path in enumerate(writable_paths)
+        ]
     if probe:
         container_spec["readinessProbe"] = probe
         container_spec["livenessProbe"] = probe
     if startup_probe:
         container_spec["startupProbe"] = startup_probe
 
+    pod_spec = {
+        "restartPolicy": "Always",
+        # Decoys never call the Kubernetes API; don't hand attackers a token
+        "automountServiceAccountToken": False,
+        "containers": [container_spec],
+    }
+    if volumes:
+        pod_spec["volumes"] = volumes
+    if DECOY_NODE_SELECTOR:
+        pod_spec["nodeSelector"] = dict(DECOY_NODE_SELECTOR)
+    if DECOY_TOLERATIONS:
+        pod_spec["tolerations"] = [dict(t) for t in DECOY_TOLERATIONS]
+    if DECOY_ANTI_AFFINITY:
+        pod_spec["affinity"] = {
+            "podAntiAffinity": {
+                "preferredDuringSchedulingIgnoredDuringExecution": [
+                    {
+                        "weight": 100,
+                        "podAffinityTerm": {
+                            "labelSelector": {
+                                "matchLabels": {"role": "decoy", "attack-id": short_id}
+                            },
+                            "topologyKey": "kubernetes.io/hostname",
+                        },
+                    }
+                ]
+            }
+        }
+
     return {
         "apiVersion": "v1",
         "kind": "Pod",
@@ -249,10 +585,7 @@ def _make_pod(
             "labels": labels,
             "annotations": annotations,
         },
-        "spec": {
-            "restartPolicy": "Always",
-            "containers": [container_spec],
-        },
+        "spec": pod_spec,
     }
 
 
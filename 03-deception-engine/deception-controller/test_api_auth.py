@@ -0,0 +1,42 @@
+"""Tests for api_auth.check_bearer (python -m unittest)."""
+
+import os
+import unittest
+
+from api_auth import check_bearer
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+
+
+class CheckBearerTest(unittest.TestCase):
+    def test_matching_token_passes(self):
+        self.assertIsNone(check_bearer("Bearer s3cret", "s3cret", "API_TOKEN"))
+
+    def test_wrong_or_missing_header_is_401(self):
+        headers = [None, "", "s3cret", "Bearer wrong", "Bearer s3cret ", "bearer x"]
+        for header in headers:
+            with self.subTest(header=header):
+                self.assertEqual(
+                    check_bearer(header, "s3cret", "API_TOKEN"), (401, "unauthorized")
+                )
+
+    def test_non_ascii_header_is_401(self):
+        self.assertEqual(check_bearer("Bearer sécret", "s3cret", "API_TOKEN")[0], 401)
+
+    def test_unset_token_fails_closed(self):
+        for header in [None, "Bearer ", "Bearer anything"]:
+            with self.subTest(header=header):
+                self.assertEqual(
+                    check_bearer(header, "", "API_TOKEN"),
+                    (503, "API_TOKEN is not configured"),
+                )
+
+    def test_analyzer_copy_is_identical(self):
+        analyzer_copy = os.path.join(HERE, "..", "traffic-analyzer", "api_auth.py")
+        with open(os.path.join(HERE, "api_auth.py")) as ours:
+            with open(analyzer_copy) as theirs:
+                self.assertEqual(ours.read(), theirs.read())
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,195 @@
+"""Tests for controller's alert, backoff and pod-health helpers (python -m unittest)."""
+
+import logging
+import time
+import unittest
+from types import SimpleNamespace
+from unittest import mock
+
+# The controller starts its Redis, TTL and pod-watch threads on import
+with mock.patch("threading.Thread.start"):
+    import controller
+
+controller.root_logger.setLevel(logging.CRITICAL)
+
+
+def make_pod(phase="Running", reason=None, waiting=None, terminated=None):
+    state = SimpleNamespace(
+        waiting=SimpleNamespace(reason=waiting) if waiting else None,
+        terminated=SimpleNamespace(reason=terminated) if terminated else None,
+    )
+    return SimpleNamespace(
+        status=SimpleNamespace(
+            phase=phase,
+            reason=reason,
+            container_statuses=[SimpleNamespace(state=state, restart_count=0)],
+        ),
+        metadata=SimpleNamespace(name="decoy-fe-abc12345", annotations={}),
+    )
+
+
+class ControllerStateTest(unittest.TestCase):
+    def setUp(self):
+        controller.recent_alerts.clear()
+        controller.spawn_failures.clear()
+        controller.controller_stats["active_decoy_sets"].clear()
+
+
+class ValidateAttackEventTest(unittest.TestCase):
+    def test_valid_addresses(self):
+        for ip in ["203.0.113.7", "2001:db8::1", "::ffff:192.0.2.1"]:
+            with self.subTest(ip=ip):
+                self.assertIsNone(controller._validate_attack_event({"source_ip": ip}))
+
+    def test_missing_or_not_a_string(self):
+        for event in [{}, {"source_ip": ""}, {"source_ip": None}, {"source_ip": 7}]:
+            with self.subTest(event=event):
+                self.assertEqual(
+                    controller._validate_attack_event(event), "missing source_ip"
+                )
+
+    def test_invalid_address(self):
+        for ip in ["unknown", "203.0.113.300", "203.0.113.7:80", " 203.0.113.7"]:
+            with self.subTest(ip=ip):
+                self.assertIn(
+                    "invalid source_ip",
+                    controller._validate_attack_event({"source_ip": ip}),
+                )
+
+    def test_scoped_ipv6_is_rejected(self):
+        self.assertIn(
+            "scoped source_ip",
+            controller._validate_attack_event({"source_ip": "fe80::1%eth0"}),
+        )
+
+
+class DuplicateAlertTest(ControllerStateTest):
+    def test_repeat_within_window_is_duplicate(self):
+        self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+        self.assertTrue(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+
+    def test_other_type_or_ip_is_not_duplicate(self):
+        self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+        self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "xss"))
+        self.assertFalse(controller._is_duplicate_alert("203.0.113.8", "sqli"))
+
+    def test_repeat_after_window_is_accepted(self):
+        self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+        key = ("203.0.113.7", "sqli")
+        controller.recent_alerts[key] -= controller.ALERT_DEDUP_SECONDS + 1
+        self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+
+    def test_disabled_with_zero_window(self):
+        with mock.patch.object(controller, "ALERT_DEDUP_SECONDS", 0):
+            self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+            self.assertFalse(controller._is_duplicate_alert("203.0.113.7", "sqli"))
+
+    def test_oldest_key_is_dropped_past_the_cap(self):
+        with mock.patch.object(controller, "ALERT_DEDUP_MAX_KEYS", 2):
+            for ip in ["203.0.113.1", "203.0.113.2", "203.0.113.3"]:
+                controller._is_duplicate_alert(ip, "sqli")
+        self.assertEqual(
+            list(controller.recent_alerts),
+            [("203.0.113.2", "sqli"), ("203.0.113.3", "sqli")],
+        )
+
+
+class SpawnBackoffTest(ControllerStateTest):
+    def test_unknown_ip_has_no_backoff(self):
+        self.assertEqual(controller._spawn_backoff_remaining("203.0.113.7"), 0.0)
+
+    def test_delay_doubles_up_to_the_max(self):
+        with mock.patch.object(
+            controller, "SPAWN_BACKOFF_BASE_SECONDS", 5
+        ), mock.patch.object(controller, "SPAWN_BACKOFF_MAX_SECONDS", 30):
+            delays = [
+                controller._record_spawn_result("203.0.113.7", False) for _ in range(5)
+            ]
+        self.assertEqual(delays, [5, 10, 20, 30, 30])
+        remaining = controller._spawn_backoff_remaining("203.0.113.7")
+        self.assertTrue(0 < remaining <= 30, remaining)
+
+    def test_success_resets_the_backoff(self):
+        controller._record_spawn_result("203.0.113.7", False)
+        self.assertGreater(controller._spawn_backoff_remaining("203.0.113.7"), 0)
+        self.assertEqual(controller._record_spawn_result("203.0.113.7", True), 0.0)
+        self.assertEqual(controller._spawn_backoff_remaining("203.0.113.7"), 0.0)
+
+    def test_expired_backoff_reads_zero(self):
+        controller._record_spawn_result("203.0.113.7", False)
+        controller.spawn_failures["203.0.113.7"]["retry_at"] = time.monotonic() - 1
+        self.assertEqual(controller._spawn_backoff_remaining("203.0.113.7"), 0.0)
+
+
+class DecoyPodProblemTest(unittest.TestCase):
+    def test_running_pod_is_healthy(self):
+        self.assertIsNone(controller._decoy_pod_problem(make_pod()))
+
+    def test_pod_without_status_is_healthy(self):
+        self.assertIsNone(controller._decoy_pod_problem(SimpleNamespace(status=None)))
+
+    def test_waiting_reasons(self):
+        for reason in sorted(controller.UNHEALTHY_WAITING_REASONS):
+            with self.subTest(reason=reason):
+                self.assertEqual(
+                    controller._decoy_pod_problem(make_pod(waiting=reason)), reason
+                )
+
+    def test_ordinary_waiting_is_healthy(self):
+        pod = make_pod(phase="Pending", waiting="ContainerCreating")
+        self.assertIsNone(controller._decoy_pod_problem(pod))
+
+    def test_oom_killed(self):
+        pod = make_pod(terminated="OOMKilled")
+        self.assertEqual(controller._decoy_pod_problem(pod), "OOMKilled")
+
+    def test_failed_phase(self):
+        pod = make_pod(phase="Failed")
+        self.assertEqual(controller._decoy_pod_problem(pod), "Failed")
+        pod = make_pod(phase="Failed", reason="Evicted")
+        self.assertEqual(controller._decoy_pod_problem(pod), "Evicted")
+
+
+class RecreateCapTest(ControllerStateTest):
+    def track_set(self, recreates):
+        controller.controller_stats["active_decoy_sets"]["abc12345"] = {
+            "attack_id": "abc12345-0000-0000-0000-000000000000",
+            "attacker_ip": "203.0.113.7",
+            "attack_type": "sqli",
+            "recreates": recreates,
+        }
+        return controller.controller_stats["active_decoy_sets"]["abc12345"]
+
+    def test_recreate_counts_against_the_cap(self):
+        info = self.track_set(recreates=0)
+        with mock.patch.object(controller, "DECOY_MAX_RECREATES", 2), mock.patch.object(
+            controller, "get_k8s_client", return_value=None
+        ) as get_k8s, mock.patch.object(controller, "publish_event") as publish:
+            controller._recreate_decoy_pod("abc12345", make_pod(), "CrashLoopBackOff")
+        self.assertEqual(info["recreates"], 1)
+        get_k8s.assert_called_once()
+        self.assertNotIn("degraded", info)
+        publish.assert_not_called()
+
+    def test_set_is_degraded_once_the_cap_is_used_up(self):
+        info = self.track_set(recreates=2)
+        with mock.patch.object(controller, "DECOY_MAX_RECREATES", 2), mock.patch.object(
+            controller, "get_k8s_client"
+        ) as get_k8s, mock.patch.object(controller, "publish_event") as publish:
+            controller._recreate_decoy_pod("abc12345", make_pod(), "CrashLoopBackOff")
+            controller._recreate_decoy_pod("abc12345", make_pod(), "CrashLoopBackOff")
+        self.assertTrue(info["degraded"])
+        self.assertEqual(info["recreates"], 2)
+        get_k8s.assert_not_called()
+        # decoy_degraded is announced only on the first refusal
+        self.assertEqual(publish.call_count, 1)
+        self.assertEqual(publish.call_args[0][1]["type"], "decoy_degraded")
+
+    def test_untracked_set_is_left_alone(self):
+        with mock.patch.object(controller, "get_k8s_client") as get_k8s:
+            controller._recreate_decoy_pod("abc12345", make_pod(), "OOMKilled")
+        get_k8s.assert_not_called()
+
+
+if __name__ == "__main__":
+    unittest.main()
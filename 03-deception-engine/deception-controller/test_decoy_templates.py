@@ -0,0 +1,45 @@
+"""Tests for decoy_templates.sanitize_ip_label (python -m unittest)."""
+
+import re
+import unittest
+
+from decoy_templates import sanitize_ip_label
+
+# Kubernetes label value: up to 63 chars, alphanumeric at both ends,
+# dashes, underscores and dots in between
+LABEL_VALUE = re.compile(r"^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$")
+
+
+class SanitizeIpLabelTest(unittest.TestCase):
+    IPS = [
+        "203.0.113.7",
+        "0.0.0.0",
+        "::",
+        "::1",
+        "fe80::",
+        "fe80::1",
+        "2001:db8::8a2e:370:7334",
+        "2001:0db8:0000:0000:0000:ff00:0042:8329",
+        "::ffff:192.0.2.1",
+    ]
+
+    def test_labels_are_valid(self):
+        for ip in self.IPS:
+            with self.subTest(ip=ip):
+                self.assertRegex(sanitize_ip_label(ip), LABEL_VALUE)
+
+    def test_ipv4_is_unchanged(self):
+        self.assertEqual(sanitize_ip_label("203.0.113.7"), "203.0.113.7")
+
+    def test_compressed_ipv6_is_padded(self):
+        self.assertEqual(sanitize_ip_label("::1"), "0--1")
+        self.assertEqual(sanitize_ip_label("fe80::"), "fe80--0")
+        self.assertEqual(sanitize_ip_label("::"), "0--0")
+
+    def test_mapping_is_one_to_one(self):
+        labels = [sanitize_ip_label(ip) for ip in self.IPS]
+        self.assertEqual(len(set(labels)), len(labels))
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -18,13 +18,15 @@ import os
 import sys
 import threading
 import time
-from collections import defaultdict, deque
+from collections import OrderedDict, defaultdict, deque
 from datetime import datetime, timezone
+from functools import lru_cache
 
 import redis
 from flask import Flask, g, jsonify, request
 
-from attack_patterns import AttackDetector
+from api_auth import require_bearer_token
+from attack_patterns import AttackDetector, load_pattern_file
 
 # ---------------------------------------------------------------------------
 # App setup
@@ -68,19 +70,91 @@ MAX_RECENT_ATTACKS = 100
 # Cleanup interval for stale rate-tracking state (seconds)
 CLEANUP_INTERVAL = 60
 
+# Optional MaxMind databases (.mmdb) for alert enrichment: a Country or City
+# DB for the country code, and an ASN DB for the network owner
+GEOIP_DB_PATH = os.environ.get("GEOIP_DB_PATH", "")
+GEOIP_ASN_DB_PATH = os.environ.get("GEOIP_ASN_DB_PATH", "")
+
+# Optional JSON file of extra detection rules (see load_pattern_file). By
+# default they are merged with the built-ins; PATTERNS_MODE=replace makes
+# each set in the file replace its built-in counterpart instead.
+PATTERNS_FILE = os.environ.get("PATTERNS_FILE", "")
+PATTERNS_MODE = os.environ.get("PATTERNS_MODE", "merge").lower()
+
+# Detectors to run, in order (comma-separated names from DETECTORS in
+# attack_patterns.py). Empty runs all of them in their default order.
+DETECTOR_NAMES = [
+    name.strip() for name in os.environ.get("DETECTORS", "").split(",") if name.strip()
+]
+
+# How many attack_detected events one request produces: "first" publishes
+# one, led by the highest-confidence finding; "all" publishes one per
+# attack type found, so a request that is both SQLi and path traversal
+# surfaces both vectors. The controller's per-IP/type dedup still applies.
+DETECTION_MODE = os.environ.get("DETECTION_MODE", "first").lower()
+
+# POST /simulate runs requests through the detector without publishing
+# anything. Off by default; enable for rule development and testing.
+SIMULATE_ENABLED = os.environ.get("SIMULATE_ENABLED", "false").lower() == "true"
+MAX_SIMULATE_BATCH = int(os.environ.get("MAX_SIMULATE_BATCH", "500"))
+
+# The router sends at most a 2KB body snippet; the headroom is for /simulate
+MAX_BODY_BYTES = int(os.environ.get("MAX_BODY_BYTES", str(1024 * 1024)))
+app.config["MAX_CONTENT_LENGTH"] = MAX_BODY_BYTES
+
+# Bearer token for /api/attackers; it answers 503 when unset
+ANALYZER_API_TOKEN = os.environ.get("ANALYZER_API_TOKEN", "")
+# Per-IP alert counts for /api/attackers; least recently alerted IPs are
+# dropped past this
+MAX_TRACKED_ALERT_IPS = 10000
+
+# Seconds /ready waits for Redis
+READY_CHECK_TIMEOUT = float(os.environ.get("READY_CHECK_TIMEOUT", "2"))
+
+if not ANALYZER_API_TOKEN:
+    app.logger.warning(
+        "ANALYZER_API_TOKEN is not set; /api/attackers is disabled (503) "
+        "until the traffic-analyzer-api-token secret exists"
+    )
+
 # ---------------------------------------------------------------------------
 # Shared state (thread-safe via GIL for simple operations)
 # ---------------------------------------------------------------------------
-detector = AttackDetector()
+detector = AttackDetector(detectors=DETECTOR_NAMES or None)
+
+
+def load_custom_patterns():
+    """Apply PATTERNS_FILE, if set. A bad file leaves the built-ins in place."""
+    if not PATTERNS_FILE:
+        return
+    try:
+        loaded, warnings = load_pattern_file(
+            PATTERNS_FILE, replace=PATTERNS_MODE == "replace"
+        )
+    except (OSError, ValueError) as e:
+        app.logger.warning(f"Patterns file {PATTERNS_FILE} not loaded: {e}")
+        return
+    for warning in warnings:
+        app.logger.warning(f"Patterns file {PATTERNS_FILE}: {warning}")
+    app.logger.info(
+        f"Loaded {loaded} custom patterns from {PATTERNS_FILE} ({PATTERNS_MODE})"
+    )
+
+
+load_custom_patterns()
 
 # Statistics counters
 stats = {
     "total_analyzed": 0,
     "total_attacks_detected": 0,
+    "total_alerts_published": 0,
     "attacks_by_type": defaultdict(int),
     "started_at": datetime.now(timezone.utc).isoformat(),
 }
 stats_lock = threading.Lock()
+# source_ip -> {"alerts_sent", "last_alert"}, least recently alerted first;
+# guarded by stats_lock
+attacker_alerts = OrderedDict()
 
 # Circular buffer for recent attacks
 recent_attacks = deque(maxlen=MAX_RECENT_ATTACKS)
@@ -128,6 +202,46 @@ def publish_attack(attack_event):
         redis_client = None
 
 
+# ---------------------------------------------------------------------------
+# GeoIP enrichment (skipped when no database is configured or readable)
+# ---------------------------------------------------------------------------
+def _open_mmdb(path):
+    if not path:
+        return None
+    try:
+        import maxminddb
+
+        reader = maxminddb.open_database(path)
+        app.logger.info(f"Loaded GeoIP database {path}")
+        return reader
+    except Exception as e:
+        app.logger.warning(f"GeoIP database {path} unavailable: {e}")
+        return None
+
+
+geoip_country_db = _open_mmdb(GEOIP_DB_PATH)
+geoip_asn_db = _open_mmdb(GEOIP_ASN_DB_PATH)
+
+
+@lru_cache(maxsize=4096)
+def geoip_lookup(ip):
+    """Return {"country", "asn", "as_org"} for an IP, or {} with no databases."""
+    if geoip_country_db is None and geoip_asn_db is None:
+        return {}
+    geo = {"country": None, "asn": None, "as_org": None}
+    try:
+        if geoip_country_db is not None:
+            record = geoip_country_db.get(ip) or {}
+            geo["country"] = (record.get("country") or {}).get("iso_code")
+        if geoip_asn_db is not None:
+            record = geoip_asn_db.get(ip) or {}
+            geo["asn"] = record.get("autonomous_system_number")
+            geo["as_org"] = record.get("autonomous_system_organization")
+    except ValueError:
+        pass  # not a valid IP
+    return geo
+
+
 # ---------------------------------------------------------------------------
 # Background cleanup thread — prevents unbounded memory from rate tracking
 # ---------------------------------------------------------------------------
@@ -165,6 +279,7 @@ def after_request(response):
         "method": request.method,
         "path": request.path,
         "source_ip": request.remote_addr,
+        "request_id": request.headers.get("X-Request-ID", ""),
         "response_code": response.status_code,
         "duration_ms": duration_ms,
     }
@@ -172,6 +287,51 @@ def after_request(response):
     return response
 
 
+@app.errorhandler(413)
+def request_too_large(_error):
+    return (
+        jsonify({"error": "Request body too large", "max_bytes": MAX_BODY_BYTES}),
+        413,
+    )
+
+
+def above_threshold(findings):
+    """
+    Findings above CONFIDENCE_THRESHOLD, highest confidence first — the
+    first one drives the verdict.
+    """
+    high_confidence = [f for f in findings if f["confidence"] > CONFIDENCE_THRESHOLD]
+    high_confidence.sort(key=lambda f: f["confidence"], reverse=True)
+    return high_confidence
+
+
+def severity_for(confidence):
+    """
+    Map a confidence to a severity level. The only copy of the cut-offs: the
+    controller reads "severity" from the attack_detected event.
+    """
+    if confidence >= 0.9:
+        return "critical"
+    if confidence >= 0.75:
+        return "high"
+    if confidence >= 0.5:
+        return "medium"
+    return "low"
+
+
+def alert_leads(high_confidence):
+    """
+    The findings that each become an attack_detected event, per
+    DETECTION_MODE: the top finding, or the top finding of each type.
+    """
+    if DETECTION_MODE != "all":
+        return high_confidence[:1]
+    leads = {}
+    for f in high_confidence:
+        leads.setdefault(f["attack_type"], f)
+    return list(leads.values())
+
+
 # ---------------------------------------------------------------------------
 # POST /analyze — Core analysis endpoint
 # ---------------------------------------------------------------------------
@@ -187,6 +347,7 @@ def analyze():
         "headers": {"User-Agent": "...", ...},
         "body": "...",
         "source_ip": "192.168.1.100",
+        "request_id": "9a1c4e0b7d2f4a6e8b3c5d7e9f1a2b3c",
         "query_params": {"id": "1' OR 1=1--"},
         "timestamp": "2024-01-15T10:30:00Z"
     }
@@ -198,7 +359,8 @@ def analyze():
         "confidence": 0.95 | null,
         "action": "redirect_to_decoy" | "allow",
         "findings_count": 3,
-        "top_finding": {...}
+        "top_finding": {...},
+        "suspicious": true/false     # allow only: findings below threshold
     }
     """
     data = request.get_json(silent=True)
@@ -216,44 +378,62 @@ def analyze():
     with stats_lock:
         stats["total_analyzed"] += 1
 
-    # Filter findings above confidence threshold
-    high_confidence = [f for f in findings if f["confidence"] > CONFIDENCE_THRESHOLD]
+    high_confidence = above_threshold(findings)
 
     if high_confidence:
-        # Sort by confidence descending — the top finding drives the response
-        high_confidence.sort(key=lambda f: f["confidence"], reverse=True)
         top = high_confidence[0]
+        leads = alert_leads(high_confidence)
+
+        for lead in leads:
+            # In "all" mode each event carries only its own type's findings
+            event_findings = high_confidence
+            if len(leads) > 1:
+                lead_type = lead["attack_type"]
+                event_findings = [
+                    f for f in high_confidence if f["attack_type"] == lead_type
+                ]
+
+            # Build the attack event for Redis
+            attack_event = {
+                "timestamp": datetime.now(timezone.utc).isoformat(),
+                "type": "attack_detected",
+                "attack_type": lead["attack_type"],
+                "confidence": lead["confidence"],
+                "severity": severity_for(lead["confidence"]),
+                "source_ip": lead["source_ip"],
+                "evidence": lead["evidence"],
+                "findings_count": len(event_findings),
+                "all_findings": event_findings,
+                **geoip_lookup(str(lead["source_ip"])),
+                "request": {
+                    "method": data.get("method"),
+                    "path": data.get("path"),
+                    "source_ip": data.get("source_ip"),
+                    "user_agent": data.get("headers", {}).get("User-Agent", ""),
+                    "request_id": data.get("request_id", ""),
+                },
+            }
 
-        # Build the attack event for Redis
-        attack_event = {
-            "timestamp": datetime.now(timezone.utc).isoformat(),
-            "type": "attack_detected",
-            "attack_type": top["attack_type"],
-            "confidence": top["confidence"],
-            "source_ip": top["source_ip"],
-            "evidence": top["evidence"],
-            "findings_count": len(high_confidence),
-            "all_findings": high_confidence,
-            "request": {
-                "method": data.get("method"),
-                "path": data.get("path"),
-                "source_ip": data.get("source_ip"),
-                "user_agent": data.get("headers", {}).get("User-Agent", ""),
-            },
-        }
+            # Publish to Redis
+            publish_attack(attack_event)
 
-        # Publish to Redis
-        publish_attack(attack_event)
+            # Store in recent attacks buffer
+            with recent_lock:
+                recent_attacks.append(attack_event)
 
-        # Update stats
+        # Update stats — one detected attack per request, whatever the mode
         with stats_lock:
             stats["total_attacks_detected"] += 1
+            stats["total_alerts_published"] += len(leads)
             for f in high_confidence:
                 stats["attacks_by_type"][f["attack_type"]] += 1
-
-        # Store in recent attacks buffer
-        with recent_lock:
-            recent_attacks.append(attack_event)
+            ip = str(top["source_ip"])
+            entry = attacker_alerts.pop(ip, None) or {"alerts_sent": 0}
+            entry["alerts_sent"] += len(leads)
+            entry["last_alert"] = datetime.now(timezone.utc).isoformat()
+            attacker_alerts[ip] = entry
+            while len(attacker_alerts) > MAX_TRACKED_ALERT_IPS:
+                attacker_alerts.popitem(last=False)
 
         return jsonify(
             {
@@ -263,9 +443,12 @@ def analyze():
                 "action": "redirect_to_decoy",
                 "findings_count": len(high_confidence),
                 "top_finding": top,
+                "alerts_published": len(leads),
             }
         )
 
+    # Below-threshold findings don't block, but the router may shadow the
+    # request to a decoy for observation
     return jsonify(
         {
             "attack": False,
@@ -274,6 +457,82 @@ def analyze():
             "action": "allow",
             "findings_count": 0,
             "top_finding": None,
+            "suspicious": bool(findings),
+        }
+    )
+
+
+# ---------------------------------------------------------------------------
+# POST /simulate — Dry-run detection for rule testing
+# ---------------------------------------------------------------------------
+@app.route("/simulate", methods=["POST"])
+def simulate():
+    """
+    Run a batch of requests through detection and report what /analyze
+    would have decided, without publishing events or touching stats.
+
+    Expects JSON body:
+    {
+        "requests": [
+            {"method": "GET", "path": "/api/products?id=1' OR 1=1--",
+             "source_ip": "10.0.0.5", "headers": {...}, ...},
+            ...
+        ]
+    }
+
+    The batch gets its own AttackDetector, so rate-based detections (brute
+    force, path scanning) see only the simulated requests, in order.
+    Disabled unless SIMULATE_ENABLED=true.
+    """
+    if not SIMULATE_ENABLED:
+        return jsonify({"error": "Simulation is disabled"}), 404
+
+    data = request.get_json(silent=True)
+    batch = data.get("requests") if isinstance(data, dict) else None
+    if not isinstance(batch, list) or not batch:
+        return jsonify({"error": "Body must contain a non-empty 'requests' list"}), 400
+    if len(batch) > MAX_SIMULATE_BATCH:
+        return (
+            jsonify({"error": f"At most {MAX_SIMULATE_BATCH} requests per batch"}),
+            400,
+        )
+
+    sandbox = AttackDetector(
+        brute_force_threshold=detector.brute_force_threshold,
+        brute_force_window=detector.brute_force_window,
+        scan_threshold=detector.scan_threshold,
+        scan_window=detector.scan_window,
+        detectors=detector.detectors,
+    )
+
+    results = []
+    for i, item in enumerate(batch):
+        if not isinstance(item, dict) or "method" not in item or "path" not in item:
+            results.append(
+                {"index": i, "error": "Missing required fields: method, path"}
+            )
+            continue
+        high_confidence = above_threshold(sandbox.analyze(item))
+        top = high_confidence[0] if high_confidence else None
+        results.append(
+            {
+                "index": i,
+                "attack": top is not None,
+                "type": top["attack_type"] if top else None,
+                "confidence": top["confidence"] if top else None,
+                "action": "redirect_to_decoy" if top else "allow",
+                "findings_count": len(high_confidence),
+                "findings": high_confidence,
+            }
+        )
+
+    return jsonify(
+        {
+            "dry_run": True,
+            "count": len(results),
+            "attacks": sum(1 for r in results if r.get("attack")),
+            "confidence_threshold": CONFIDENCE_THRESHOLD,
+            "results": results,
         }
     )
 
@@ -290,6 +549,8 @@ def get_stats():
             {
                 "total_analyzed": stats["total_analyzed"],
                 "total_attacks_detected": stats["total_attacks_detected"],
+                "total_alerts_published": stats["total_alerts_published"],
+                "detection_mode": DETECTION_MODE,
                 "attacks_by_type": dict(stats["attacks_by_type"]),
                 "detection_rate": (
                     round(stats["total_attacks_detected"] / stats["total_analyzed"], 4)
@@ -297,6 +558,7 @@ def get_stats():
                     else 0.0
                 ),
                 "confidence_threshold": CONFIDENCE_THRESHOLD,
+                "detectors": detector.detectors,
                 "started_at": stats["started_at"],
                 "uptime_seconds": round(
                     (
@@ -328,6 +590,63 @@ def get_recent_attacks():
     )
 
 
+# ---------------------------------------------------------------------------
+# GET /api/attackers, POST /api/attackers/reset — Inspect/reset per-IP state
+# ---------------------------------------------------------------------------
+# Fails closed: 503 for every call while ANALYZER_API_TOKEN is unset
+require_token = require_bearer_token(ANALYZER_API_TOKEN, "ANALYZER_API_TOKEN")
+
+
+@app.route("/api/attackers")
+@require_token
+def get_attackers():
+    """
+    Per-IP detector state, most recently seen first. Each gunicorn worker
+    tracks its own state, so this reflects only the worker that answered.
+    """
+    with stats_lock:
+        states = detector.get_attacker_states()
+        for ip, alert in attacker_alerts.items():
+            states.setdefault(ip, {}).update(alert)
+    attackers = [{"source_ip": ip, **state} for ip, state in states.items()]
+    attackers.sort(
+        key=lambda a: max(a.get("last_seen", ""), a.get("last_alert", "")),
+        reverse=True,
+    )
+    return jsonify({"count": len(attackers), "attackers": attackers})
+
+
+@app.route("/api/attackers/reset", methods=["POST"])
+@require_token
+def reset_attackers():
+    """
+    Clear one IP's detector state and alert count ({"source_ip": "..."}),
+    or every IP's ({"all": true}).
+    """
+    data = request.get_json(silent=True)
+    if not isinstance(data, dict):
+        return jsonify({"error": "Request body must be valid JSON"}), 400
+    source_ip = data.get("source_ip")
+    if data.get("all") is True:
+        with stats_lock:
+            cleared = len(set(detector.get_attacker_states()) | set(attacker_alerts))
+            detector.reset_attacker_state()
+            attacker_alerts.clear()
+        return jsonify({"reset": cleared, "all": True})
+    if not isinstance(source_ip, str) or not source_ip:
+        return jsonify({"error": 'Provide "source_ip" or "all": true'}), 400
+
+    with stats_lock:
+        cleared = detector.reset_attacker_state(source_ip)
+        had_alerts = attacker_alerts.pop(source_ip, None) is not None
+    if not (cleared or had_alerts):
+        return (
+            jsonify({"error": "no state tracked for this IP", "source_ip": source_ip}),
+            404,
+        )
+    return jsonify({"reset": 1, "source_ip": source_ip})
+
+
 # ---------------------------------------------------------------------------
 # GET /health — Health check
 # ---------------------------------------------------------------------------
@@ -353,6 +672,40 @@ def health():
     )
 
 
+# ---------------------------------------------------------------------------
+# GET /ready — Readiness check (downstream dependencies)
+# ---------------------------------------------------------------------------
+@app.route("/ready")
+def ready():
+    """
+    Readiness: 503 unless Redis answers, since detections could not be
+    published. /health stays the liveness check.
+    """
+    client = redis.from_url(
+        REDIS_URL,
+        socket_connect_timeout=READY_CHECK_TIMEOUT,
+        socket_timeout=READY_CHECK_TIMEOUT,
+    )
+    try:
+        client.ping()
+        checks = {"redis": {"ok": True}}
+    except Exception as e:
+        checks = {"redis": {"ok": False, "error": str(e).strip()[:200]}}
+    finally:
+        client.close()
+    ok = checks["redis"]["ok"]
+    return (
+        jsonify(
+            {
+                "status": "ready" if ok else "not_ready",
+                "service": "traffic-analyzer",
+                "checks": checks,
+            }
+        ),
+        200 if ok else 503,
+    )
+
+
 # ---------------------------------------------------------------------------
 # Dev server (not used in production — gunicorn is the entrypoint)
 # ---------------------------------------------------------------------------
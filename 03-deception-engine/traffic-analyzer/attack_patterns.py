@@ -2,8 +2,9 @@
 Attack Patterns — Detection logic for the traffic analyzer.
 
 Classifies HTTP requests into attack categories using compiled regex,
-rate tracking, and behavioral heuristics. Each detection method returns
-a list of findings (may be empty), where each finding is a dict:
+rate tracking, and behavioral heuristics. Detectors are registered in
+DETECTORS and run in order by AttackDetector.analyze(); each returns a
+list of findings (may be empty), where each finding is a dict:
 
     {
         "attack_type": str,      # e.g. "sqli", "xss", "path_traversal"
@@ -17,6 +18,7 @@ a list of findings (may be empty), where each finding is a dict:
 All regex patterns are compiled at module load time for performance.
 """
 
+import json
 import re
 import time
 from collections import defaultdict
@@ -207,6 +209,110 @@ _DIR_ENUM_PATHS = [
 ]
 
 
+# ---------------------------------------------------------------------------
+# Custom pattern sets — loaded from a JSON file (e.g. a mounted ConfigMap)
+# ---------------------------------------------------------------------------
+# Maps the keys accepted in a patterns file to the lists the detectors scan.
+# The lists are updated in place so AttackDetector picks up changes without
+# being rebuilt.
+PATTERN_SETS = {
+    "sqli": _SQLI_PATTERNS,
+    "xss": _XSS_PATTERNS,
+    "path_traversal": _PATH_TRAVERSAL_PATTERNS,
+    "scanner_ua": _SCANNER_UA_PATTERNS,
+    "dir_enum": _DIR_ENUM_PATHS,
+}
+
+# Snapshot of the built-in rules so repeated loads start from a clean base
+_BUILTIN_PATTERN_SETS = {name: list(rules) for name, rules in PATTERN_SETS.items()}
+
+
+def load_pattern_file(path, replace=False):
+    """
+    Load extra detection rules from a JSON file.
+
+    The file maps pattern-set names to lists of rules:
+
+        {
+            "sqli": [
+                {"pattern": "benchmark\\s*\\(", "evidence": "BENCHMARK()",
+                 "confidence": 0.9}
+            ],
+            "dir_enum": [
+                {"pattern": "^/internal-admin\\b", "evidence": "/internal-admin probe",
+                 "confidence": 0.85, "ignore_case": false}
+            ]
+        }
+
+    Rules are appended to the built-in ones, or — with replace=True — stand
+    in for them in every set the file mentions. Sets the file omits keep
+    their built-ins. Rules that are malformed or don't compile are skipped.
+
+    Returns (loaded, warnings): the number of rules accepted and a list of
+    human-readable messages for everything skipped. Raises OSError or
+    ValueError if the file can't be read or isn't a JSON object.
+    """
+    with open(path) as f:
+        spec = json.load(f)
+    if not isinstance(spec, dict):
+        raise ValueError(f"{path}: expected a JSON object of pattern sets")
+
+    loaded = 0
+    warnings = []
+    custom = {}
+    for name, rules in spec.items():
+        if name not in PATTERN_SETS:
+            warnings.append(f"unknown pattern set {name!r} ignored")
+            continue
+        if not isinstance(rules, list):
+            warnings.append(f"{name}: expected a list of rules")
+            continue
+        compiled = []
+        for i, rule in enumerate(rules):
+            try:
+                flags = re.IGNORECASE if rule.get("ignore_case", True) else 0
+                regex = re.compile(rule["pattern"], flags)
+                evidence = str(rule.get("evidence") or rule["pattern"])
+                confidence = float(rule.get("confidence", 0.8))
+            except (AttributeError, KeyError, TypeError, ValueError, re.error) as e:
+                warnings.append(f"{name}[{i}]: skipped invalid rule: {e}")
+                continue
+            if not 0.0 <= confidence <= 1.0:
+                warnings.append(f"{name}[{i}]: confidence {confidence} out of range")
+                continue
+            compiled.append((regex, evidence, confidence))
+        custom[name] = compiled
+        loaded += len(compiled)
+
+    for name, rules in PATTERN_SETS.items():
+        base = [] if replace and name in custom else _BUILTIN_PATTERN_SETS[name]
+        rules[:] = base + custom.get(name, [])
+
+    return loaded, warnings
+
+
+# ============================================================================
+# Detection pipeline — ordered registry of detectors
+# ============================================================================
+# Maps detector name to a function called as
+#     fn(detector, request_data, scan_fields, source_ip, timestamp, summary)
+# that returns a list of findings. Registration order is the default run
+# order; AttackDetector(detectors=[...]) runs a subset in a chosen order.
+DETECTORS = {}
+
+
+def register_detector(name):
+    """Decorator adding a detector to the pipeline under the given name."""
+
+    def decorator(fn):
+        if name in DETECTORS:
+            raise ValueError(f"detector {name!r} is already registered")
+        DETECTORS[name] = fn
+        return fn
+
+    return decorator
+
+
 # ============================================================================
 # AttackDetector — stateful detector with rate tracking
 # ============================================================================
@@ -228,6 +334,9 @@ class AttackDetector:
         Max unique paths per IP within the scan window.
     scan_window : float
         Time window in seconds for scan detection.
+    detectors : list[str] or None
+        Names from DETECTORS to run, in order. None runs all of them in
+        registration order. Unknown names raise ValueError.
     """
 
     def __init__(
@@ -236,7 +345,15 @@ class AttackDetector:
         brute_force_window=30.0,
         scan_threshold=10,
         scan_window=15.0,
+        detectors=None,
     ):
+        self.detectors = list(DETECTORS) if detectors is None else list(detectors)
+        unknown = [name for name in self.detectors if name not in DETECTORS]
+        if unknown:
+            raise ValueError(
+                f"unknown detectors {unknown}; available: {', '.join(DETECTORS)}"
+            )
+
         self.brute_force_threshold = brute_force_threshold
         self.brute_force_window = brute_force_window
         self.scan_threshold = scan_threshold
@@ -251,7 +368,7 @@ class AttackDetector:
     # -----------------------------------------------------------------------
     def analyze(self, request_data):
         """
-        Run all detection methods against a request and return findings.
+        Run the configured detectors against a request and return findings.
 
         Parameters
         ----------
@@ -280,34 +397,19 @@ class AttackDetector:
         # Collect all text fields to scan for pattern-based detections
         scan_fields = _collect_scan_fields(request_data)
 
-        # --- 1. SQL Injection ---
-        findings.extend(self._detect_sqli(scan_fields, source_ip, now, summary))
-
-        # --- 2. XSS ---
-        findings.extend(self._detect_xss(scan_fields, source_ip, now, summary))
-
-        # --- 3. Path Traversal ---
-        findings.extend(
-            self._detect_path_traversal(scan_fields, source_ip, now, summary)
-        )
-
-        # --- 4. Brute Force ---
-        bf = self._detect_brute_force(request_data, source_ip, now, summary)
-        if bf:
-            findings.append(bf)
-
-        # --- 5. Reconnaissance / Port Scanning ---
-        findings.extend(self._detect_recon(request_data, source_ip, now, summary))
-
-        # --- 6. Directory Enumeration ---
-        findings.extend(self._detect_dir_enum(request_data, source_ip, now, summary))
+        for name in self.detectors:
+            detect = DETECTORS[name]
+            findings.extend(
+                detect(self, request_data, scan_fields, source_ip, now, summary)
+            )
 
         return findings
 
     # -----------------------------------------------------------------------
-    # Detection methods
+    # Detection methods — registered in run order
     # -----------------------------------------------------------------------
-    def _detect_sqli(self, scan_fields, source_ip, timestamp, summary):
+    @register_detector("sqli")
+    def _detect_sqli(self, request_data, scan_fields, source_ip, timestamp, summary):
         """Check all request fields against SQL injection patterns."""
         findings = []
         seen = set()  # avoid duplicate findings from same pattern
@@ -327,7 +429,8 @@ class AttackDetector:
                     )
         return findings
 
-    def _detect_xss(self, scan_fields, source_ip, timestamp, summary):
+    @register_detector("xss")
+    def _detect_xss(self, request_data, scan_fields, source_ip, timestamp, summary):
         """Check all request fields against XSS patterns."""
         findings = []
         seen = set()
@@ -347,7 +450,10 @@ class AttackDetector:
                     )
         return findings
 
-    def _detect_path_traversal(self, scan_fields, source_ip, timestamp, summary):
+    @register_detector("path_traversal")
+    def _detect_path_traversal(
+        self, request_data, scan_fields, source_ip, timestamp, summary
+    ):
         """Check for directory traversal patterns in path and parameters."""
         findings = []
         seen = set()
@@ -367,7 +473,10 @@ class AttackDetector:
                     )
         return findings
 
-    def _detect_brute_force(self, request_data, source_ip, timestamp, summary):
+    @register_detector("brute_force")
+    def _detect_brute_force(
+        self, request_data, scan_fields, source_ip, timestamp, summary
+    ):
         """
         Track POST requests to auth-like endpoints per source IP.
 
@@ -378,7 +487,7 @@ class AttackDetector:
         path = request_data.get("path", "")
 
         if method != "POST":
-            return None
+            return []
 
         # Check if path looks like an authentication endpoint
         auth_patterns = [
@@ -394,7 +503,7 @@ class AttackDetector:
 
         is_auth = any(p.search(path) for p in auth_patterns)
         if not is_auth:
-            return None
+            return []
 
         now = time.monotonic()
 
@@ -411,18 +520,21 @@ class AttackDetector:
         if count >= self.brute_force_threshold:
             # Confidence scales with how far above the threshold we are
             confidence = min(0.60 + (count - self.brute_force_threshold) * 0.08, 0.98)
-            return _make_finding(
-                "brute_force",
-                confidence,
-                source_ip,
-                f"{count} auth attempts in {self.brute_force_window}s to {path}",
-                timestamp,
-                summary,
-            )
+            return [
+                _make_finding(
+                    "brute_force",
+                    confidence,
+                    source_ip,
+                    f"{count} auth attempts in {self.brute_force_window}s to {path}",
+                    timestamp,
+                    summary,
+                )
+            ]
 
-        return None
+        return []
 
-    def _detect_recon(self, request_data, source_ip, timestamp, summary):
+    @register_detector("recon")
+    def _detect_recon(self, request_data, scan_fields, source_ip, timestamp, summary):
         """
         Detect reconnaissance scanning: rapid unique path enumeration
         and known scanner user-agents.
@@ -473,7 +585,10 @@ class AttackDetector:
 
         return findings
 
-    def _detect_dir_enum(self, request_data, source_ip, timestamp, summary):
+    @register_detector("dir_enum")
+    def _detect_dir_enum(
+        self, request_data, scan_fields, source_ip, timestamp, summary
+    ):
         """Check if the requested path matches known sensitive/admin endpoints."""
         path = request_data.get("path", "")
         findings = []
@@ -523,6 +638,50 @@ class AttackDetector:
         for ip in stale_ips:
             del self._path_history[ip]
 
+    def get_attacker_states(self):
+        """
+        Per-IP rate-tracking state for diagnostics, keyed by source IP.
+
+        Times are converted from the monotonic clock to ISO 8601 wall time.
+        """
+        mono_now = time.monotonic()
+        wall_now = time.time()
+
+        def wall(t):
+            return datetime.fromtimestamp(
+                wall_now - (mono_now - t), timezone.utc
+            ).isoformat()
+
+        states = {}
+        for ip in set(self._auth_attempts) | set(self._path_history):
+            attempts = self._auth_attempts.get(ip, [])
+            history = self._path_history.get(ip, [])
+            seen = attempts + [t for t, _ in history]
+            if not seen:
+                continue
+            states[ip] = {
+                "auth_attempts": len(attempts),
+                "path_requests": len(history),
+                "unique_paths": len(set(p for _, p in history)),
+                "first_seen": wall(min(seen)),
+                "last_seen": wall(max(seen)),
+            }
+        return states
+
+    def reset_attacker_state(self, source_ip=None):
+        """
+        Forget one IP's rate-tracking state, or every IP's when source_ip is
+        None. Returns the number of IPs cleared.
+        """
+        if source_ip is None:
+            cleared = len(set(self._auth_attempts) | set(self._path_history))
+            self._auth_attempts.clear()
+            self._path_history.clear()
+            return cleared
+        had_auth = self._auth_attempts.pop(source_ip, None) is not None
+        had_paths = self._path_history.pop(source_ip, None) is not None
+        return int(had_auth or had_paths)
+
     def get_tracking_stats(self):
         """Return current state sizes for diagnostics."""
         return {
@@ -0,0 +1,81 @@
+"""Tests for the analyzer's verdict helpers and DETECTION_MODE (python -m unittest)."""
+
+import logging
+import unittest
+from unittest import mock
+
+import analyzer
+
+analyzer.app.logger.setLevel(logging.CRITICAL)
+
+
+def finding(attack_type, confidence):
+    return {"attack_type": attack_type, "confidence": confidence}
+
+
+FINDINGS = [
+    finding("path_traversal", 0.9),
+    finding("sqli", 0.8),
+    finding("sqli", 0.95),
+    finding("xss", 0.3),
+]
+
+
+class AboveThresholdTest(unittest.TestCase):
+    def test_drops_low_confidence_and_sorts_highest_first(self):
+        with mock.patch.object(analyzer, "CONFIDENCE_THRESHOLD", 0.6):
+            high = analyzer.above_threshold(list(FINDINGS))
+        self.assertEqual([f["confidence"] for f in high], [0.95, 0.9, 0.8])
+
+    def test_threshold_itself_is_not_above(self):
+        with mock.patch.object(analyzer, "CONFIDENCE_THRESHOLD", 0.8):
+            high = analyzer.above_threshold([finding("sqli", 0.8)])
+        self.assertEqual(high, [])
+
+
+class AlertLeadsTest(unittest.TestCase):
+    def leads(self, mode):
+        with mock.patch.object(analyzer, "CONFIDENCE_THRESHOLD", 0.6):
+            high = analyzer.above_threshold(list(FINDINGS))
+        with mock.patch.object(analyzer, "DETECTION_MODE", mode):
+            return analyzer.alert_leads(high)
+
+    def test_first_mode_publishes_the_top_finding(self):
+        self.assertEqual(self.leads("first"), [finding("sqli", 0.95)])
+
+    def test_all_mode_publishes_the_top_finding_per_type(self):
+        self.assertEqual(
+            self.leads("all"),
+            [finding("sqli", 0.95), finding("path_traversal", 0.9)],
+        )
+
+    def test_unknown_mode_behaves_like_first(self):
+        self.assertEqual(self.leads("bogus"), [finding("sqli", 0.95)])
+
+    def test_no_findings_no_leads(self):
+        for mode in ["first", "all"]:
+            with self.subTest(mode=mode), mock.patch.object(
+                analyzer, "DETECTION_MODE", mode
+            ):
+                self.assertEqual(analyzer.alert_leads([]), [])
+
+
+class SeverityForTest(unittest.TestCase):
+    def test_cut_offs(self):
+        cases = [
+            (1.0, "critical"),
+            (0.9, "critical"),
+            (0.89, "high"),
+            (0.75, "high"),
+            (0.74, "medium"),
+            (0.5, "medium"),
+            (0.49, "low"),
+            (0.0, "low"),
+        ]
+        for confidence, severity in cases:
+            with self.subTest(confidence=confidence):
+                self.assertEqual(analyzer.severity_for(confidence), severity)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,60 @@
+"""Tests for the attack_patterns detector registry (python -m unittest)."""
+
+import unittest
+
+from attack_patterns import DETECTORS, AttackDetector, register_detector
+
+# Both SQL injection and path traversal
+MIXED_REQUEST = {
+    "method": "GET",
+    "path": "/../../etc/passwd",
+    "query_params": {"q": "' OR 1=1 --"},
+    "source_ip": "203.0.113.7",
+}
+
+
+def attack_types(findings):
+    return [f["attack_type"] for f in findings]
+
+
+class DetectorRegistryTest(unittest.TestCase):
+    def test_builtin_detectors_in_run_order(self):
+        self.assertEqual(
+            list(DETECTORS),
+            ["sqli", "xss", "path_traversal", "brute_force", "recon", "dir_enum"],
+        )
+
+    def test_default_runs_every_detector(self):
+        self.assertEqual(AttackDetector().detectors, list(DETECTORS))
+        types = attack_types(AttackDetector().analyze(MIXED_REQUEST))
+        self.assertIn("sqli", types)
+        self.assertIn("path_traversal", types)
+
+    def test_subset_runs_only_those_detectors(self):
+        detector = AttackDetector(detectors=["path_traversal"])
+        types = attack_types(detector.analyze(MIXED_REQUEST))
+        self.assertTrue(types)
+        self.assertEqual(set(types), {"path_traversal"})
+
+    def test_subset_runs_in_the_given_order(self):
+        detector = AttackDetector(detectors=["path_traversal", "sqli"])
+        types = attack_types(detector.analyze(MIXED_REQUEST))
+        self.assertEqual(types[0], "path_traversal")
+        self.assertEqual(types[-1], "sqli")
+
+    def test_unknown_detector_is_rejected(self):
+        with self.assertRaisesRegex(ValueError, "unknown detectors"):
+            AttackDetector(detectors=["sqli", "nope"])
+
+    def test_duplicate_registration_is_rejected(self):
+        with self.assertRaisesRegex(ValueError, "already registered"):
+            register_detector("sqli")(lambda *args: [])
+        self.assertEqual(DETECTORS["sqli"], AttackDetector._detect_sqli)
+
+    def test_benign_request_has_no_findings(self):
+        request = {"method": "GET", "path": "/products/42", "source_ip": "203.0.113.7"}
+        self.assertEqual(AttackDetector().analyze(request), [])
+
+
+if __name__ == "__main__":
+    unittest.main()
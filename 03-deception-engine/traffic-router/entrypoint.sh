@@ -4,7 +4,49 @@ set -e
 DNS=$(grep -m1 '^nameserver' /etc/resolv.conf | awk '{print $2}')
 DNS="${DNS:-10.43.0.10}"
 
-sed -i "s/__RESOLVER__/${DNS}/g" /usr/local/openresty/nginx/conf/nginx.conf
+CONF=/usr/local/openresty/nginx/conf/nginx.conf
 
-echo "traffic-router starting — resolver=${DNS}"
+sed -i "s/__RESOLVER__/${DNS}/g" "$CONF"
+
+# nginx size/time syntax, e.g. 1m / 512k and 10s / 1m; anything else would
+# stop nginx from starting, so fall back to the defaults
+MAX_BODY_SIZE="${MAX_BODY_SIZE:-1m}"
+CLIENT_TIMEOUT="${CLIENT_TIMEOUT:-10s}"
+if ! echo "$MAX_BODY_SIZE" | grep -Eq '^[0-9]+[kKmM]?$'; then
+    echo "WARNING: invalid MAX_BODY_SIZE '${MAX_BODY_SIZE}' — using 1m"
+    MAX_BODY_SIZE=1m
+fi
+if ! echo "$CLIENT_TIMEOUT" | grep -Eq '^[0-9]+(ms|s|m)?$'; then
+    echo "WARNING: invalid CLIENT_TIMEOUT '${CLIENT_TIMEOUT}' — using 10s"
+    CLIENT_TIMEOUT=10s
+fi
+sed -i "s/__MAX_BODY_SIZE__/${MAX_BODY_SIZE}/g; s/__CLIENT_TIMEOUT__/${CLIENT_TIMEOUT}/g" "$CONF"
+
+# Idle keep-alive connections kept per worker to the real frontend
+UPSTREAM_KEEPALIVE="${UPSTREAM_KEEPALIVE:-16}"
+if ! echo "$UPSTREAM_KEEPALIVE" | grep -Eq '^[1-9][0-9]*$'; then
+    echo "WARNING: invalid UPSTREAM_KEEPALIVE '${UPSTREAM_KEEPALIVE}' — using 16"
+    UPSTREAM_KEEPALIVE=16
+fi
+sed -i "s/__UPSTREAM_KEEPALIVE__/${UPSTREAM_KEEPALIVE}/g" "$CONF"
+
+# Optional HTTPS alongside plain HTTP. Both files must be present; a missing
+# secret mount falls back to HTTP only rather than failing the pod.
+TLS_PORT="${TLS_PORT:-443}"
+if [ -n "${TLS_CERT_FILE:-}" ] && [ -n "${TLS_KEY_FILE:-}" ] \
+    && [ -r "$TLS_CERT_FILE" ] && [ -r "$TLS_KEY_FILE" ]; then
+    sed -i "s|#__TLS_LISTEN__|listen ${TLS_PORT} ssl; ssl_certificate ${TLS_CERT_FILE}; ssl_certificate_key ${TLS_KEY_FILE}; ssl_protocols TLSv1.2 TLSv1.3;|" "$CONF"
+    echo "traffic-router TLS enabled on :${TLS_PORT} — cert=${TLS_CERT_FILE}"
+else
+    sed -i "/#__TLS_LISTEN__/d" "$CONF"
+    if [ -n "${TLS_CERT_FILE:-}${TLS_KEY_FILE:-}" ]; then
+        echo "WARNING: TLS_CERT_FILE/TLS_KEY_FILE set but not both readable — serving plain HTTP only"
+    fi
+fi
+
+if [ -z "${INTERNAL_API_TOKEN:-}" ]; then
+    echo "WARNING: INTERNAL_API_TOKEN is not set — /internal/* route API is protected by source-IP allowlist only"
+fi
+
+echo "traffic-router starting — resolver=${DNS} max_body=${MAX_BODY_SIZE} client_timeout=${CLIENT_TIMEOUT} upstream_keepalive=${UPSTREAM_KEEPALIVE}"
 exec /usr/local/openresty/bin/openresty -g 'daemon off;'
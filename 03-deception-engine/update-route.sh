@@ -12,12 +12,18 @@
 #   ./update-route.sh list
 #
 # Environment:
-#   ROUTER_URL  — Base URL of the traffic router (default: http://localhost:30080)
+#   ROUTER_URL          — Base URL of the traffic router (default: http://localhost:30080)
+#   INTERNAL_API_TOKEN  — Bearer token, required if the router was started with one
 
 set -euo pipefail
 
 ROUTER_URL="${ROUTER_URL:-http://localhost:30080}"
 
+AUTH_ARGS=()
+if [[ -n "${INTERNAL_API_TOKEN:-}" ]]; then
+    AUTH_ARGS=(-H "Authorization: Bearer ${INTERNAL_API_TOKEN}")
+fi
+
 usage() {
     echo "Usage:"
     echo "  $0 add    <attacker_ip> <decoy_frontend_url>"
@@ -42,7 +48,7 @@ case "${1:-}" in
         URL="${3:?Error: decoy_frontend_url required}"
         echo "Adding route: ${IP} -> ${URL}"
         curl -sS -X POST "${ROUTER_URL}/internal/add-route" \
-            -H "Content-Type: application/json" \
+            -H "Content-Type: application/json" "${AUTH_ARGS[@]}" \
             -d "{\"attacker_ip\":\"${IP}\",\"decoy_frontend_url\":\"${URL}\"}" \
             --connect-timeout 5 \
             --max-time 10 | fmt_json
@@ -52,7 +58,7 @@ case "${1:-}" in
         IP="${2:?Error: attacker_ip required}"
         echo "Removing route: ${IP}"
         curl -sS -X POST "${ROUTER_URL}/internal/remove-route" \
-            -H "Content-Type: application/json" \
+            -H "Content-Type: application/json" "${AUTH_ARGS[@]}" \
             -d "{\"attacker_ip\":\"${IP}\"}" \
             --connect-timeout 5 \
             --max-time 10 | fmt_json
@@ -60,7 +66,7 @@ case "${1:-}" in
         ;;
     list)
         echo "Current routes:"
-        curl -sS "${ROUTER_URL}/internal/routes" \
+        curl -sS "${ROUTER_URL}/internal/routes" "${AUTH_ARGS[@]}" \
             --connect-timeout 5 \
             --max-time 10 | fmt_json
         echo
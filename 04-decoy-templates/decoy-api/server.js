@@ -2,15 +2,23 @@ const express = require("express");
 const { createClient } = require("redis");
 
 const app = express();
-app.use(express.json({ limit: "1mb" }));
 
 const PORT = parseInt(process.env.PORT || "8081", 10);
 const REDIS_URL =
   process.env.REDIS_URL || "redis://redis.monitoring.svc.cluster.local:6379";
 const REDIS_CHANNEL = "decoy_interaction";
 const DECOY_ID = process.env.DECOY_ID || "decoy-api";
+const POD_NAME = process.env.POD_NAME || "";
 const ATTACK_ID = process.env.ATTACK_ID || "";
 const ATTACKER_IP = process.env.ATTACKER_IP || "";
+const SHUTDOWN_TIMEOUT_MS = parseInt(process.env.SHUTDOWN_TIMEOUT_MS || "10000", 10);
+// Slow-client limits: the whole request, and its headers
+const REQUEST_TIMEOUT_MS = parseInt(process.env.REQUEST_TIMEOUT_MS || "30000", 10);
+const HEADERS_TIMEOUT_MS = parseInt(process.env.HEADERS_TIMEOUT_MS || "10000", 10);
+// Larger bodies get a 413; express size syntax (e.g. "1mb", "512kb")
+const MAX_BODY_SIZE = process.env.MAX_BODY_SIZE || "1mb";
+
+app.use(express.json({ limit: MAX_BODY_SIZE }));
 
 let redisClient = null;
 let redisReady = false;
@@ -31,22 +39,25 @@ async function connectRedis() {
 }
 
 async function publishInteraction(req, resCode) {
-  if (!redisReady || !redisClient) {
+  // Kubelet probes are not attacker engagement
+  if (!redisReady || !redisClient || req.path === "/health") {
     return;
   }
   const event = {
     timestamp: new Date().toISOString(),
     type: "decoy_interaction",
     decoy_id: DECOY_ID,
+    decoy_pod: POD_NAME,
     decoy_type: "api",
     attack_id: ATTACK_ID,
     attacker_ip: ATTACKER_IP,
-    source_ip: req.ip || req.socket.remoteAddress || "",
+    source_ip: req.headers["x-real-ip"] || req.ip || req.socket.remoteAddress || "",
     method: req.method,
     path: req.originalUrl,
     headers: req.headers,
     query_params: req.query || {},
     body: req.body || {},
+    request_id: req.headers["x-request-id"] || "",
     response_code: resCode,
   };
   try {
@@ -134,7 +145,17 @@ app.use(
   })
 );
 
-app.listen(PORT, "0.0.0.0", () => {
+// Oversized or malformed JSON bodies: plain JSON errors, not Express's
+// default HTML error page
+app.use((err, _req, res, next) => {
+  if (res.headersSent) return next(err);
+  if (err.status === 413) {
+    return res.status(413).json({ error: "Payload Too Large" });
+  }
+  res.status(400).json({ error: "Bad Request" });
+});
+
+const server = app.listen(PORT, "0.0.0.0", () => {
   process.stdout.write(
     JSON.stringify({
       timestamp: new Date().toISOString(),
@@ -146,5 +167,35 @@ app.listen(PORT, "0.0.0.0", () => {
   );
 });
 
+server.requestTimeout = REQUEST_TIMEOUT_MS;
+server.headersTimeout = Math.min(HEADERS_TIMEOUT_MS, REQUEST_TIMEOUT_MS);
+
 // Do not block startup on Redis connection; decoy should serve immediately.
 connectRedis().catch(() => {});
+
+// QUIT waits for pending publishes
+function shutdown(signal) {
+  process.stdout.write(
+    JSON.stringify({
+      timestamp: new Date().toISOString(),
+      level: "INFO",
+      service: "decoy-api",
+      message: `received ${signal}, draining connections`,
+      decoy_id: DECOY_ID,
+    }) + "\n"
+  );
+
+  setTimeout(() => process.exit(1), SHUTDOWN_TIMEOUT_MS).unref();
+  server.close(async () => {
+    if (redisClient && redisReady) {
+      try {
+        await redisClient.quit();
+      } catch (_) {}
+    }
+    process.exit(0);
+  });
+  server.closeIdleConnections();
+}
+
+process.once("SIGTERM", () => shutdown("SIGTERM"));
+process.once("SIGINT", () => shutdown("SIGINT"));
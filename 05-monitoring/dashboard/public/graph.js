@@ -1,6 +1,15 @@
 (() => {
   const MAX_EVENT_FEED = 260;
   const TRANSIENT_EDGE_TTL_MS = 18000;
+  const STATS_REFRESH_MS = 15000;
+  // decoy_spawned-channel events that tear a set down rather than create one
+  const DECOY_CLEANUP_TYPES = new Set(['decoy_expired', 'decoy_removed']);
+  // ...and ones about a single pod of a set that stays up
+  const DECOY_POD_STATUS = {
+    decoy_unhealthy: (event) => event.reason || 'Unhealthy',
+    decoy_recreated: () => 'Recreating',
+    decoy_degraded: (event) => `Degraded (${event.reason || 'dead'})`,
+  };
 
   const state = {
     config: null,
@@ -697,14 +706,20 @@
     if (eventType === 'attack_detected') {
       return `ATTACK ${event.attack_type || 'unknown'} from ${event.source_ip || 'unknown ip'} confidence=${event.confidence || '?'}`;
     }
-    if (eventType === 'decoy_spawned' && event.type === 'decoy_expired') {
+    if (eventType === 'decoy_spawned' && DECOY_CLEANUP_TYPES.has(event.type)) {
       return `DECOY CLEANUP attack=${event.attack_id || '-'} reason=${event.reason || '-'}`;
     }
+    if (eventType === 'decoy_spawned' && event.type === 'decoy_unhealthy') {
+      return `DECOY UNHEALTHY pod=${event.decoy_pod || '-'} reason=${event.reason || '-'} restarts=${event.restarts || 0}`;
+    }
+    if (eventType === 'decoy_spawned' && (event.type === 'decoy_recreated' || event.type === 'decoy_degraded')) {
+      return `DECOY ${event.type === 'decoy_recreated' ? 'RECREATED' : 'DEGRADED'} pod=${event.decoy_pod || '-'} reason=${event.reason || '-'} recreates=${event.recreates || 0}`;
+    }
     if (eventType === 'decoy_spawned') {
       return `DECOY SPAWN attack=${event.attack_id || '-'} ip=${event.attacker_ip || '-'} pods=${(event.decoy_pods || []).length}`;
     }
     if (eventType === 'decoy_interaction') {
-      return `DECOY INTERACTION attacker=${event.attacker_ip || '-'} target=${event.decoy_pod || event.pod_name || '-'}`;
+      return `DECOY INTERACTION attacker=${event.source_ip || event.attacker_ip || '-'} target=${event.decoy_pod || event.pod_name || event.decoy_id || '-'} ${event.method || ''} ${event.path || ''}`.trim();
     }
     if (eventType === 'pod_update') {
       return `POD ${event.watch_type || '-'} ${event.namespace || '-'} / ${event.pod_name || '-'} status=${event.status || '-'}`;
@@ -901,7 +916,16 @@
   }
 
   function handleDecoySpawned(event) {
-    if (event.type === 'decoy_expired') {
+    // Crashed/recreated decoy pod: the set is still up, only the pod's status changes
+    if (DECOY_POD_STATUS[event.type]) {
+      const node = state.nodeById.get(`pod:decoy-pool:${event.decoy_pod}`);
+      if (node) {
+        node.status = DECOY_POD_STATUS[event.type](event);
+      }
+      return;
+    }
+
+    if (DECOY_CLEANUP_TYPES.has(event.type)) {
       state.stats.decoysCleaned += 1;
       if (event.attack_id) {
         state.decoySetIds.delete(event.attack_id);
@@ -1173,6 +1197,29 @@
     }
   }
 
+  // Counters from the live stream start at zero on every page load; the
+  // controller's /api/stats holds the real totals, so resync from it.
+  async function loadControllerStats() {
+    if (!state.config.controllerApi) {
+      return;
+    }
+    try {
+      const root = state.config.controllerApi.replace(/\/$/, '');
+      const res = await fetch(`${root}/api/stats`);
+      if (!res.ok) {
+        throw new Error(`HTTP ${res.status}`);
+      }
+
+      const payload = await res.json();
+      state.stats.attacksDetected = Math.max(state.stats.attacksDetected, payload.total_attacks_received || 0);
+      state.stats.decoysSpawned = Math.max(state.stats.decoysSpawned, payload.total_spawned_sets || 0);
+      state.stats.decoysCleaned = Math.max(state.stats.decoysCleaned, payload.total_cleaned_sets || 0);
+      updateSummaryPanels();
+    } catch (err) {
+      addEventFeedEntry('event-system', `Failed to load controller stats: ${err.message}`, new Date().toISOString());
+    }
+  }
+
   function connectWebSocket() {
     if (state.ws) {
       state.ws.close();
@@ -1328,6 +1375,8 @@
     }
 
     await loadRecentEvents();
+    await loadControllerStats();
+    setInterval(loadControllerStats, STATS_REFRESH_MS);
     connectWebSocket();
     updateSummaryPanels();
   }
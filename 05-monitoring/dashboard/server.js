@@ -3,12 +3,21 @@ const path = require('path');
 const { createProxyMiddleware } = require('http-proxy-middleware');
 
 const PORT = parseInt(process.env.PORT || '8080', 10);
+const SHUTDOWN_TIMEOUT_MS = parseInt(process.env.SHUTDOWN_TIMEOUT_MS || '10000', 10);
+// Client request/header receive timeouts
+const REQUEST_TIMEOUT_MS = parseInt(process.env.REQUEST_TIMEOUT_MS || '30000', 10);
+const HEADERS_TIMEOUT_MS = parseInt(process.env.HEADERS_TIMEOUT_MS || '10000', 10);
 const EVENT_COLLECTOR_WS =
   process.env.EVENT_COLLECTOR_WS ||
   'ws://event-collector.monitoring.svc.cluster.local:8090';
 const EVENT_COLLECTOR_API =
   process.env.EVENT_COLLECTOR_API ||
   'http://event-collector.monitoring.svc.cluster.local:8091';
+const DECEPTION_CONTROLLER_API =
+  process.env.DECEPTION_CONTROLLER_API ||
+  'http://deception-controller.deception-gateway.svc.cluster.local:8086';
+// /ready gives the event collector this long to answer /health
+const READY_CHECK_TIMEOUT_MS = parseInt(process.env.READY_CHECK_TIMEOUT_MS || '2000', 10);
 
 const app = express();
 
@@ -16,6 +25,23 @@ app.get('/health', (_req, res) => {
   res.json({ status: 'ok', service: 'dashboard' });
 });
 
+async function checkDependency(url) {
+  try {
+    const res = await fetch(url, { signal: AbortSignal.timeout(READY_CHECK_TIMEOUT_MS) });
+    return res.ok ? { ok: true } : { ok: false, error: `HTTP ${res.status}` };
+  } catch (err) {
+    return { ok: false, error: err.message };
+  }
+}
+
+// Readiness: 503 unless the event collector (snapshots + live feed) answers;
+// /health stays a cheap liveness check
+app.get('/ready', async (_req, res) => {
+  const checks = { 'event-collector': await checkDependency(`${EVENT_COLLECTOR_API}/health`) };
+  const ok = checks['event-collector'].ok;
+  res.status(ok ? 200 : 503).json({ status: ok ? 'ready' : 'not_ready', service: 'dashboard', checks });
+});
+
 app.get('/config', (_req, res) => {
   const host = _req.headers.host || `localhost:${PORT}`;
   const protocol = _req.protocol || 'http';
@@ -23,6 +49,7 @@ app.get('/config', (_req, res) => {
   res.json({
     eventCollectorWs: `${wsProtocol}://${host}/ws`,
     eventCollectorApi: `${protocol}://${host}/proxy`,
+    controllerApi: `${protocol}://${host}/controller`,
   });
 });
 
@@ -35,6 +62,26 @@ app.use(
   })
 );
 
+// Server-side aggregates (/api/stats) so counters survive a page refresh.
+// Only that read-only endpoint is exposed; the controller's write APIs
+// (/api/deploy, /api/unblock, ...) must not be reachable via the NodePort.
+app.get(
+  '/controller/api/stats',
+  createProxyMiddleware({
+    target: DECEPTION_CONTROLLER_API,
+    changeOrigin: true,
+    pathRewrite: { '^/controller': '' },
+  })
+);
+
+app.all('/controller/api/stats', (_req, res) => {
+  res.set('Allow', 'GET').status(405).json({ error: 'method not allowed' });
+});
+
+app.all('/controller*', (_req, res) => {
+  res.status(404).json({ error: 'not found' });
+});
+
 const wsProxy = createProxyMiddleware({
   target: EVENT_COLLECTOR_WS,
   changeOrigin: true,
@@ -58,8 +105,30 @@ const server = app.listen(PORT, '0.0.0.0', () => {
     message: `Dashboard server listening on port ${PORT}`,
     event_collector_ws: EVENT_COLLECTOR_WS,
     event_collector_api: EVENT_COLLECTOR_API,
+    deception_controller_api: DECEPTION_CONTROLLER_API,
   };
   process.stdout.write(JSON.stringify(log) + '\n');
 });
 
+server.requestTimeout = REQUEST_TIMEOUT_MS;
+server.headersTimeout = Math.min(HEADERS_TIMEOUT_MS, REQUEST_TIMEOUT_MS);
+
 server.on('upgrade', wsProxy.upgrade);
+
+// Proxied WebSocket connections never go idle, so shutdown cuts them at
+// SHUTDOWN_TIMEOUT_MS
+function shutdown(signal) {
+  const log = {
+    timestamp: new Date().toISOString(),
+    level: 'INFO',
+    message: `Received ${signal}, draining connections (timeout ${SHUTDOWN_TIMEOUT_MS}ms)`,
+  };
+  process.stdout.write(JSON.stringify(log) + '\n');
+
+  setTimeout(() => process.exit(1), SHUTDOWN_TIMEOUT_MS).unref();
+  server.close(() => process.exit(0));
+  server.closeIdleConnections();
+}
+
+process.once('SIGTERM', () => shutdown('SIGTERM'));
+process.once('SIGINT', () => shutdown('SIGINT'));
@@ -2,17 +2,20 @@ import asyncio
 import json
 import logging
 import os
+import re
+import signal
 import sys
 import threading
 import time
 import uuid
-from collections import deque
+from collections import OrderedDict, defaultdict, deque
 from datetime import datetime, timezone
 from typing import Any, Dict, List, Optional, Tuple
 
 import redis
 import websockets
-from flask import Flask, jsonify
+from flask import Flask, jsonify, request
+from werkzeug.serving import WSGIRequestHandler
 from kubernetes import client, config, watch
 from kubernetes.client.rest import ApiException
 
@@ -20,6 +23,13 @@ SERVICE_NAME = "event-collector"
 WEBSOCKET_PORT = int(os.environ.get("WEBSOCKET_PORT", "8090"))
 REST_PORT = int(os.environ.get("REST_PORT", "8091"))
 GRAPH_INTERVAL_SECONDS = int(os.environ.get("GRAPH_INTERVAL_SECONDS", "5"))
+WS_SEND_TIMEOUT_SECONDS = float(os.environ.get("WS_SEND_TIMEOUT_SECONDS", "5"))
+WS_PING_INTERVAL_SECONDS = float(os.environ.get("WS_PING_INTERVAL_SECONDS", "20"))
+# Idle/stalled REST connections are dropped after this, so slow clients
+# can't pin server threads
+REST_SOCKET_TIMEOUT_SECONDS = float(os.environ.get("REST_SOCKET_TIMEOUT_SECONDS", "15"))
+# Seconds /ready waits for Redis
+READY_CHECK_TIMEOUT = float(os.environ.get("READY_CHECK_TIMEOUT", "2"))
 REDIS_URL = os.environ.get("REDIS_URL", "redis://redis.monitoring.svc.cluster.local:6379")
 MONITORED_NAMESPACES = [
     ns.strip()
@@ -29,6 +39,18 @@ MONITORED_NAMESPACES = [
     ).split(",")
     if ns.strip()
 ]
+# Optional ';'-separated Kubernetes label selectors for pod_update events; a
+# pod is reported if it matches ANY of them (empty = every pod). Each
+# selector supports the usual syntax: k=v, k!=v, k in (a,b), k notin (a,b),
+# k, !k — comma-joined requirements within a selector are ANDed.
+POD_LABEL_SELECTORS = os.environ.get("POD_LABEL_SELECTORS", "")
+# Comma-separated origins allowed to call the REST API from a browser ("*"
+# for any); unset = same-origin only
+CORS_ALLOWED_ORIGINS = [
+    origin.strip()
+    for origin in os.environ.get("CORS_ALLOWED_ORIGINS", "").split(",")
+    if origin.strip()
+]
 
 REDIS_CHANNELS = [
     "attack_detected",
@@ -36,7 +58,29 @@ REDIS_CHANNELS = [
     "decoy_interaction",
     "routing_update",
     "pod_status",
+    "router_traffic",
 ]
+# High-volume per-request reports from the traffic-router (REPORT_ROUTING);
+# aggregated for /api/router-stats instead of being broadcast
+ROUTER_TRAFFIC_CHANNEL = "router_traffic"
+# Per-request samples kept for /api/timeseries (oldest dropped first)
+ROUTER_SAMPLE_LIMIT = int(os.environ.get("ROUTER_SAMPLE_LIMIT", "100000"))
+TIMESERIES_MAX_BUCKETS = 500
+TIMESERIES_MAX_WINDOW_SECONDS = 24 * 3600
+# Per-IP session summaries from router_traffic; least recently seen IPs
+# are dropped past SESSION_MAX_IPS, and distinct paths stop being
+# recorded (but are still counted as requests) past SESSION_MAX_PATHS
+SESSION_MAX_IPS = int(os.environ.get("SESSION_MAX_IPS", "10000"))
+SESSION_MAX_PATHS = 500
+# attack_detected events kept for /api/attacks (oldest dropped first)
+ATTACK_SAMPLE_LIMIT = int(os.environ.get("ATTACK_SAMPLE_LIMIT", "50000"))
+ATTACK_TOP_SIGNATURES = 20
+# router_traffic reports failing validation are dropped and counted;
+# string fields longer than this (above nginx's 8k request line) are rejected
+ROUTER_REPORT_ROUTES = {"decoy", "legit"}
+ROUTER_REPORT_STRING_FIELDS = ("source_ip", "method", "path", "request_id", "routed_to")
+ROUTER_REPORT_NUMBER_FIELDS = ("latency_ms", "upstream_ms", "bytes_sent", "sample_rate")
+ROUTER_REPORT_MAX_CHARS = 8192
 
 KNOWN_SERVICE_CONNECTIONS = [
     ("ecommerce-real", "frontend", "ecommerce-real", "product-service"),
@@ -83,6 +127,8 @@ logging.getLogger("werkzeug").setLevel(logging.WARNING)
 recent_events: deque = deque(maxlen=MAX_RECENT_EVENTS)
 recent_events_lock = threading.Lock()
 
+latest_graph_snapshot: Optional[Dict[str, Any]] = None
+
 connected_clients: set = set()
 
 event_loop: Optional[asyncio.AbstractEventLoop] = None
@@ -99,6 +145,24 @@ local_event_ids: deque = deque()
 local_event_id_set: set = set()
 local_event_lock = threading.Lock()
 
+router_stats: Dict[str, Any] = {
+    "total": 0.0,
+    "by_route": defaultdict(float),
+    "by_status": defaultdict(float),
+    "latency_ms_sum": defaultdict(float),
+    "last_seen": None,
+    "rejected": 0,
+    "last_rejection": None,
+}
+router_stats_lock = threading.Lock()
+# (received_at epoch seconds, route, latency_ms or None, weight)
+router_samples: deque = deque(maxlen=ROUTER_SAMPLE_LIMIT)
+# (received_at epoch seconds, attack_type, severity, evidence)
+attack_samples: deque = deque(maxlen=ATTACK_SAMPLE_LIMIT)
+attack_samples_lock = threading.Lock()
+# source_ip -> session summary, least recently seen first
+ip_sessions: "OrderedDict[str, Dict[str, Any]]" = OrderedDict()
+
 attacker_routes: Dict[str, Dict[str, Any]] = {}
 attack_id_to_ip: Dict[str, str] = {}
 routes_lock = threading.Lock()
@@ -129,9 +193,69 @@ def infer_role(namespace: str, labels: Dict[str, str]) -> str:
     return "real"
 
 
+_SET_REQUIREMENT_RE = re.compile(r"^([\w./-]+)\s+(in|notin)\s+\((.*)\)$")
+
+
+def parse_label_selector(selector: str) -> List[Tuple[str, str, set]]:
+    """Parse one label selector into (key, operator, values) requirements."""
+    requirements = []
+    # Split on commas that are not inside an in/notin value list
+    for part in re.split(r",(?![^()]*\))", selector):
+        part = part.strip()
+        if not part:
+            continue
+        set_match = _SET_REQUIREMENT_RE.match(part)
+        if set_match:
+            key, op, values = set_match.groups()
+            requirements.append(
+                (key, op, {v.strip() for v in values.split(",") if v.strip()})
+            )
+        elif "!=" in part:
+            key, value = part.split("!=", 1)
+            requirements.append((key.strip(), "notin", {value.strip()}))
+        elif "=" in part:
+            key, value = part.split("==" if "==" in part else "=", 1)
+            requirements.append((key.strip(), "in", {value.strip()}))
+        elif part.startswith("!"):
+            requirements.append((part[1:].strip(), "!exists", set()))
+        else:
+            requirements.append((part, "exists", set()))
+    return requirements
+
+
+def labels_match(requirements: List[Tuple[str, str, set]], labels: Dict[str, str]) -> bool:
+    for key, op, values in requirements:
+        if op == "in" and labels.get(key) not in values:
+            return False
+        # Like Kubernetes, != / notin also match pods without the label
+        if op == "notin" and key in labels and labels[key] in values:
+            return False
+        if op == "exists" and key not in labels:
+            return False
+        if op == "!exists" and key in labels:
+            return False
+    return True
+
+
+POD_SELECTORS = [
+    parse_label_selector(s) for s in POD_LABEL_SELECTORS.split(";") if s.strip()
+]
+
+
+def pod_is_monitored(namespace: str, labels: Dict[str, str]) -> bool:
+    if namespace not in MONITORED_NAMESPACES:
+        return False
+    if not POD_SELECTORS:
+        return True
+    return any(labels_match(reqs, labels) for reqs in POD_SELECTORS)
+
+
 def append_recent_event(event: Dict[str, Any]) -> None:
+    global latest_graph_snapshot
     with recent_events_lock:
         recent_events.append(event)
+        if event.get("event_type") == "graph_snapshot":
+            latest_graph_snapshot = event
 
 
 def mark_local_event_id(event_id: str) -> None:
@@ -259,11 +383,30 @@ def get_k8s_client() -> Optional[client.CoreV1Api]:
 # ---------------------------------------------------------------------------
 # WebSocket broadcast pipeline
 # ---------------------------------------------------------------------------
+async def send_initial_snapshot(websocket) -> None:
+    # Late joiners would otherwise render an empty graph until the next
+    # snapshot tick; recent non-graph events are loaded over REST.
+    with recent_events_lock:
+        snapshot = latest_graph_snapshot
+
+    if snapshot is None:
+        return
+
+    try:
+        await asyncio.wait_for(
+            websocket.send(json.dumps(snapshot, default=str)),
+            WS_SEND_TIMEOUT_SECONDS,
+        )
+    except Exception as exc:
+        logger.warning(f"Failed to send initial graph snapshot: {exc}")
+
+
 async def websocket_handler(websocket):
     connected_clients.add(websocket)
     logger.info(f"WebSocket client connected. active_clients={len(connected_clients)}")
 
     try:
+        await send_initial_snapshot(websocket)
         async for _ in websocket:
             continue
     except Exception:
@@ -277,12 +420,19 @@ async def websocket_handler(websocket):
 
 async def _send_to_client(client_socket, payload: str) -> bool:
     try:
-        await client_socket.send(payload)
+        await asyncio.wait_for(client_socket.send(payload), WS_SEND_TIMEOUT_SECONDS)
         return True
     except Exception:
         return False
 
 
+async def _close_client(client_socket) -> None:
+    try:
+        await asyncio.wait_for(client_socket.close(), WS_SEND_TIMEOUT_SECONDS)
+    except Exception:
+        pass
+
+
 async def broadcast_event(event: Dict[str, Any]) -> None:
     append_recent_event(event)
 
@@ -299,6 +449,11 @@ async def broadcast_event(event: Dict[str, Any]) -> None:
     for client_socket, ok in zip(clients, results):
         if not ok:
             connected_clients.discard(client_socket)
+            logger.warning(
+                f"Evicted unresponsive WebSocket client. active_clients={len(connected_clients)}"
+            )
+            # Closing performs its own handshake; don't hold up the dispatcher on it.
+            asyncio.create_task(_close_client(client_socket))
 
 
 async def event_dispatcher_loop() -> None:
@@ -343,6 +498,114 @@ def update_attacker_routes(event: Dict[str, Any]) -> None:
                 attack_id_to_ip.pop(attack_id, None)
 
 
+def validate_router_request(event: Dict[str, Any]) -> Optional[str]:
+    """Return why a router_traffic report is malformed, or None if it's usable."""
+    if event.get("route") not in ROUTER_REPORT_ROUTES:
+        return f"route must be one of {sorted(ROUTER_REPORT_ROUTES)}"
+    status = event.get("status")
+    if isinstance(status, bool) or not isinstance(status, int) or not 100 <= status <= 599:
+        return "status must be an integer between 100 and 599"
+    for field in ROUTER_REPORT_NUMBER_FIELDS:
+        value = event.get(field)
+        if value is None:
+            continue
+        if isinstance(value, bool) or not isinstance(value, (int, float)) or value < 0:
+            return f"{field} must be a non-negative number"
+    sample_rate = event.get("sample_rate")
+    if sample_rate is not None and not 0 < sample_rate <= 1:
+        return "sample_rate must be greater than 0 and at most 1"
+    for field in ROUTER_REPORT_STRING_FIELDS:
+        value = event.get(field)
+        if value is None:
+            continue
+        if not isinstance(value, str):
+            return f"{field} must be a string"
+        if len(value) > ROUTER_REPORT_MAX_CHARS:
+            return f"{field} exceeds {ROUTER_REPORT_MAX_CHARS} characters"
+    return None
+
+
+def record_router_request(event: Dict[str, Any]) -> None:
+    error = validate_router_request(event)
+    if error:
+        with router_stats_lock:
+            router_stats["rejected"] += 1
+            router_stats["last_rejection"] = {"reason": error, "at": utc_now()}
+            rejected = router_stats["rejected"]
+        # First rejection, then every 1000th, so a broken publisher can't flood the log
+        if rejected == 1 or rejected % 1000 == 0:
+            logger.warning(f"Dropped malformed router_traffic report ({rejected} so far): {error}")
+        return
+
+    route = str(event.get("route") or "unknown")
+    status = event.get("status")
+    status_class = f"{int(status) // 100}xx" if isinstance(status, int) else "unknown"
+    latency = event.get("latency_ms")
+    # A report sampled at rate r stands in for 1/r requests
+    weight = 1 / (event.get("sample_rate") or 1)
+    with router_stats_lock:
+        router_stats["total"] += weight
+        router_stats["by_route"][route] += weight
+        router_stats["by_status"][f"{route}:{status_class}"] += weight
+        if isinstance(latency, (int, float)):
+            router_stats["latency_ms_sum"][route] += latency * weight
+        router_stats["last_seen"] = event.get("timestamp") or utc_now()
+        router_samples.append(
+            (
+                time.time(),
+                route,
+                latency if isinstance(latency, (int, float)) else None,
+                weight,
+            )
+        )
+        if event.get("source_ip"):
+            record_session(str(event["source_ip"]), event, route, status_class)
+
+
+def record_session(ip: str, event: Dict[str, Any], route: str, status_class: str) -> None:
+    """Fold one router request into the IP's session. Caller holds router_stats_lock."""
+    now = time.time()
+    second = int(now)
+    session = ip_sessions.get(ip)
+    if session is None:
+        session = {
+            "first_seen": now,
+            "total": 0,
+            "paths": set(),
+            "by_status": defaultdict(int),
+            "by_route": defaultdict(int),
+            "current_second": second,
+            "current_count": 0,
+            "peak_rps": 0,
+        }
+        ip_sessions[ip] = session
+        while len(ip_sessions) > SESSION_MAX_IPS:
+            ip_sessions.popitem(last=False)
+    else:
+        ip_sessions.move_to_end(ip)
+
+    session["last_seen"] = now
+    session["total"] += 1
+    session["by_status"][status_class] += 1
+    session["by_route"][route] += 1
+    path = event.get("path")
+    if path and len(session["paths"]) < SESSION_MAX_PATHS:
+        session["paths"].add(str(path))
+    if session["current_second"] != second:
+        session["current_second"] = second
+        session["current_count"] = 0
+    session["current_count"] += 1
+    session["peak_rps"] = max(session["peak_rps"], session["current_count"])
+
+
+def record_attack(event: Dict[str, Any]) -> None:
+    attack_type = str(event.get("attack_type") or "unknown")
+    severity = str(event.get("severity") or "unknown")
+    evidence = str(event.get("evidence") or "")[:200]
+    with attack_samples_lock:
+        attack_samples.append((time.time(), attack_type, severity, evidence))
+
+
 def redis_subscriber_loop() -> None:
     while True:
         try:
@@ -373,9 +636,16 @@ def redis_subscriber_loop() -> None:
                 if "event_type" not in event:
                     event["event_type"] = event.get("type", channel)
 
+                if channel == ROUTER_TRAFFIC_CHANNEL:
+                    record_router_request(event)
+                    continue
+
                 if channel == "routing_update":
                     update_attacker_routes(event)
 
+                if channel == "attack_detected":
+                    record_attack(event)
+
                 event_id = event.get("event_id")
                 if isinstance(event_id, str) and is_local_event_id(event_id):
                     continue
@@ -435,7 +705,7 @@ def kubernetes_pod_watch_loop() -> None:
                 timeout_seconds=60,
             ):
                 event = build_pod_update_event(k8s_watch_event)
-                if not event:
+                if not event or not pod_is_monitored(event["namespace"], event["labels"]):
                     continue
 
                 event_id = event.get("event_id")
@@ -625,6 +895,21 @@ def graph_snapshot_loop() -> None:
 # ---------------------------------------------------------------------------
 # Flask REST API
 # ---------------------------------------------------------------------------
+@app.after_request
+def add_cors_headers(response):
+    # Flask answers OPTIONS preflights itself, so they pass through here too
+    origin = request.headers.get("Origin")
+    if not origin or not ("*" in CORS_ALLOWED_ORIGINS or origin in CORS_ALLOWED_ORIGINS):
+        return response
+    response.headers["Access-Control-Allow-Origin"] = origin
+    response.headers.add("Vary", "Origin")
+    if request.method == "OPTIONS":
+        response.headers["Access-Control-Allow-Methods"] = "GET, OPTIONS"
+        response.headers["Access-Control-Allow-Headers"] = "Content-Type"
+        response.headers["Access-Control-Max-Age"] = "600"
+    return response
+
+
 @app.route("/api/events/recent", methods=["GET"])
 def get_recent_events():
     with recent_events_lock:
@@ -639,6 +924,175 @@ def get_recent_events():
     )
 
 
+@app.route("/api/router-stats", methods=["GET"])
+def get_router_stats():
+    with router_stats_lock:
+        by_route = dict(router_stats["by_route"])
+        avg_latency = {
+            route: round(router_stats["latency_ms_sum"][route] / count, 1)
+            for route, count in by_route.items()
+            if count
+        }
+        # From the bounded ip_sessions, so only IPs still tracked there rank
+        top_decoy_ips = sorted(
+            (
+                (ip, session["by_route"]["decoy"])
+                for ip, session in ip_sessions.items()
+                if session["by_route"].get("decoy")
+            ),
+            key=lambda kv: kv[1],
+            reverse=True,
+        )[:20]
+        payload = {
+            "service": SERVICE_NAME,
+            "total": round(router_stats["total"]),
+            "by_route": {route: round(count) for route, count in by_route.items()},
+            "by_status": {key: round(count) for key, count in router_stats["by_status"].items()},
+            "avg_latency_ms": avg_latency,
+            "top_decoy_ips": [{"source_ip": ip, "requests": n} for ip, n in top_decoy_ips],
+            "last_seen": router_stats["last_seen"],
+            "rejected": router_stats["rejected"],
+            "last_rejection": router_stats["last_rejection"],
+        }
+    return jsonify(payload)
+
+
+@app.route("/api/session/<ip>", methods=["GET"])
+def get_session(ip: str):
+    with router_stats_lock:
+        session = ip_sessions.get(ip)
+        if session is None:
+            return jsonify({"error": "no traffic recorded for this IP", "source_ip": ip}), 404
+        first_seen = session["first_seen"]
+        last_seen = session["last_seen"]
+        paths = sorted(session["paths"])
+        payload = {
+            "service": SERVICE_NAME,
+            "source_ip": ip,
+            "first_seen": datetime.fromtimestamp(first_seen, timezone.utc).isoformat(),
+            "last_seen": datetime.fromtimestamp(last_seen, timezone.utc).isoformat(),
+            "duration_seconds": round(last_seen - first_seen, 1),
+            "total_requests": session["total"],
+            "distinct_paths": len(paths),
+            "paths": paths,
+            "paths_truncated": len(paths) >= SESSION_MAX_PATHS,
+            "by_status": dict(session["by_status"]),
+            "by_route": dict(session["by_route"]),
+            "peak_requests_per_second": session["peak_rps"],
+        }
+    return jsonify(payload)
+
+
+@app.route("/api/attacks", methods=["GET"])
+def get_attacks():
+    window = parse_duration(request.args.get("window", "1h"))
+    if not window:
+        return jsonify({"error": "window must be a duration like 30s, 5m, 1h"}), 400
+    if window > TIMESERIES_MAX_WINDOW_SECONDS:
+        return jsonify({"error": f"window must be at most {TIMESERIES_MAX_WINDOW_SECONDS}s"}), 400
+
+    start = time.time() - window
+    by_type: Dict[str, int] = defaultdict(int)
+    by_severity: Dict[str, int] = defaultdict(int)
+    by_type_severity: Dict[str, Dict[str, int]] = defaultdict(lambda: defaultdict(int))
+    signatures: Dict[Tuple[str, str], int] = defaultdict(int)
+    with attack_samples_lock:
+        samples = [sample for sample in attack_samples if sample[0] >= start]
+    for _, attack_type, severity, evidence in samples:
+        by_type[attack_type] += 1
+        by_severity[severity] += 1
+        by_type_severity[attack_type][severity] += 1
+        signatures[(attack_type, evidence)] += 1
+
+    top_signatures = sorted(signatures.items(), key=lambda kv: kv[1], reverse=True)
+    return jsonify(
+        {
+            "service": SERVICE_NAME,
+            "window_seconds": window,
+            "total": len(samples),
+            "by_type": dict(sorted(by_type.items(), key=lambda kv: kv[1], reverse=True)),
+            "by_severity": dict(by_severity),
+            "by_type_severity": {t: dict(v) for t, v in by_type_severity.items()},
+            "top_signatures": [
+                {"attack_type": attack_type, "evidence": evidence, "count": count}
+                for (attack_type, evidence), count in top_signatures[:ATTACK_TOP_SIGNATURES]
+            ],
+        }
+    )
+
+
+def parse_duration(value: str) -> Optional[int]:
+    """Parse "30s", "5m", "1h" or plain seconds; None if malformed."""
+    match = re.fullmatch(r"\s*(\d+)\s*([smh]?)\s*", value or "")
+    if not match:
+        return None
+    return int(match.group(1)) * {"": 1, "s": 1, "m": 60, "h": 3600}[match.group(2)]
+
+
+def timeseries_buckets(
+    samples: List[Tuple[float, str, Optional[float], float]],
+    now: float,
+    bucket: int,
+    bucket_count: int,
+) -> List[Dict[str, Any]]:
+    """Fold router samples into bucket_count buckets of bucket seconds ending at now."""
+    # Buckets are aligned to multiples of the bucket size; the last one is
+    # the (partial) current bucket
+    end = (int(now) // bucket + 1) * bucket
+    start = end - bucket_count * bucket
+    counts = [0.0] * bucket_count
+    routes = [defaultdict(float) for _ in range(bucket_count)]
+    latency_sum = [0.0] * bucket_count
+    latency_n = [0.0] * bucket_count
+    for received_at, route, latency, weight in samples:
+        index = int((received_at - start) // bucket)
+        if not 0 <= index < bucket_count:
+            continue
+        counts[index] += weight
+        routes[index][route] += weight
+        if latency is not None:
+            latency_sum[index] += latency * weight
+            latency_n[index] += weight
+
+    return [
+        {
+            "timestamp": datetime.fromtimestamp(start + i * bucket, timezone.utc).isoformat(),
+            "count": round(counts[i]),
+            "by_route": {route: round(count) for route, count in routes[i].items()},
+            "avg_latency": round(latency_sum[i] / latency_n[i], 1) if latency_n[i] else None,
+        }
+        for i in range(bucket_count)
+    ]
+
+
+@app.route("/api/timeseries", methods=["GET"])
+def get_timeseries():
+    bucket = parse_duration(request.args.get("bucket", "1m"))
+    window = parse_duration(request.args.get("window", "1h"))
+    if not bucket or not window:
+        return jsonify({"error": "bucket and window must be durations like 30s, 5m, 1h"}), 400
+    if window > TIMESERIES_MAX_WINDOW_SECONDS:
+        return jsonify({"error": f"window must be at most {TIMESERIES_MAX_WINDOW_SECONDS}s"}), 400
+    bucket_count = -(-window // bucket)
+    if bucket < 1 or bucket_count > TIMESERIES_MAX_BUCKETS:
+        return (
+            jsonify({"error": f"window/bucket must give at most {TIMESERIES_MAX_BUCKETS} buckets"}),
+            400,
+        )
+
+    with router_stats_lock:
+        samples = list(router_samples)
+    buckets = timeseries_buckets(samples, time.time(), bucket, bucket_count)
+    return jsonify(
+        {
+            "service": SERVICE_NAME,
+            "bucket_seconds": bucket,
+            "window_seconds": bucket_count * bucket,
+            "buckets": buckets,
+        }
+    )
+
+
 @app.route("/health", methods=["GET"])
 def health():
     with recent_events_lock:
@@ -659,9 +1113,55 @@ def health():
     )
 
 
+def ping_redis() -> None:
+    redis_client = redis.from_url(
+        REDIS_URL,
+        socket_connect_timeout=READY_CHECK_TIMEOUT,
+        socket_timeout=READY_CHECK_TIMEOUT,
+    )
+    try:
+        redis_client.ping()
+    finally:
+        redis_client.close()
+
+
+@app.route("/ready", methods=["GET"])
+def ready():
+    # Redis carries every event the collector streams; /health stays the
+    # liveness check
+    try:
+        ping_redis()
+        checks = {"redis": {"ok": True}}
+    except Exception as exc:
+        checks = {"redis": {"ok": False, "error": str(exc).strip()[:200]}}
+    ok = all(check["ok"] for check in checks.values())
+    return (
+        jsonify(
+            {
+                "status": "ready" if ok else "not_ready",
+                "service": SERVICE_NAME,
+                "checks": checks,
+            }
+        ),
+        200 if ok else 503,
+    )
+
+
+class TimeoutRequestHandler(WSGIRequestHandler):
+    # Applied to each accepted socket by socketserver's StreamRequestHandler
+    timeout = REST_SOCKET_TIMEOUT_SECONDS
+
+
 def run_rest_server() -> None:
     logger.info(f"Starting REST server on 0.0.0.0:{REST_PORT}")
-    app.run(host="0.0.0.0", port=REST_PORT, debug=False, threaded=True, use_reloader=False)
+    app.run(
+        host="0.0.0.0",
+        port=REST_PORT,
+        debug=False,
+        threaded=True,
+        use_reloader=False,
+        request_handler=TimeoutRequestHandler,
+    )
 
 
 # ---------------------------------------------------------------------------
@@ -689,11 +1189,24 @@ async def async_main() -> None:
     start_background_threads()
     dispatcher_task = asyncio.create_task(event_dispatcher_loop())
 
-    logger.info(f"Starting WebSocket server on 0.0.0.0:{WEBSOCKET_PORT}")
-    async with websockets.serve(websocket_handler, "0.0.0.0", WEBSOCKET_PORT):
-        await asyncio.Future()
+    # Python as PID 1 ignores SIGTERM by default; turn it into a clean stop so
+    # leaving the serve() context closes client sockets with 1001 Going Away.
+    stop = event_loop.create_future()
+    for sig in (signal.SIGTERM, signal.SIGINT):
+        event_loop.add_signal_handler(sig, lambda s=sig: stop.done() or stop.set_result(s))
 
-    await dispatcher_task
+    logger.info(f"Starting WebSocket server on 0.0.0.0:{WEBSOCKET_PORT}")
+    async with websockets.serve(
+        websocket_handler,
+        "0.0.0.0",
+        WEBSOCKET_PORT,
+        ping_interval=WS_PING_INTERVAL_SECONDS,
+        ping_timeout=WS_PING_INTERVAL_SECONDS,
+    ):
+        received = await stop
+        logger.info(f"Received {signal.Signals(received).name}, closing WebSocket clients")
+
+    dispatcher_task.cancel()
 
 
 def main() -> None:
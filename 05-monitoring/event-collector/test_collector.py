@@ -0,0 +1,160 @@
+"""Tests for the collector's selector, router-report and timeseries helpers (python -m unittest)."""
+
+import logging
+import unittest
+
+import collector
+
+collector.logger.setLevel(logging.CRITICAL)
+
+
+class LabelSelectorTest(unittest.TestCase):
+    def test_parse_equality_and_existence(self):
+        self.assertEqual(
+            collector.parse_label_selector("app=web, tier!=db,role, !canary"),
+            [
+                ("app", "in", {"web"}),
+                ("tier", "notin", {"db"}),
+                ("role", "exists", set()),
+                ("canary", "!exists", set()),
+            ],
+        )
+
+    def test_parse_double_equals(self):
+        self.assertEqual(
+            collector.parse_label_selector("app==web"), [("app", "in", {"web"})]
+        )
+
+    def test_parse_set_requirements_keep_their_commas(self):
+        self.assertEqual(
+            collector.parse_label_selector("role in (decoy, real),env notin (dev)"),
+            [("role", "in", {"decoy", "real"}), ("env", "notin", {"dev"})],
+        )
+
+    def test_empty_selector_matches_everything(self):
+        self.assertEqual(collector.parse_label_selector(" , "), [])
+        self.assertTrue(collector.labels_match([], {"app": "web"}))
+
+    def test_labels_match(self):
+        requirements = collector.parse_label_selector(
+            "role in (decoy,real),tier!=db,!canary"
+        )
+        cases = [
+            ({"role": "decoy"}, True),
+            ({"role": "real", "tier": "web"}, True),
+            ({"role": "other"}, False),
+            ({}, False),
+            ({"role": "decoy", "tier": "db"}, False),
+            ({"role": "decoy", "canary": "true"}, False),
+        ]
+        for labels, expected in cases:
+            with self.subTest(labels=labels):
+                self.assertIs(collector.labels_match(requirements, labels), expected)
+
+    def test_notin_matches_pods_without_the_label(self):
+        requirements = collector.parse_label_selector("env notin (dev)")
+        self.assertTrue(collector.labels_match(requirements, {}))
+        self.assertFalse(collector.labels_match(requirements, {"env": "dev"}))
+
+    def test_exists(self):
+        requirements = collector.parse_label_selector("attack-id")
+        self.assertTrue(collector.labels_match(requirements, {"attack-id": "abc"}))
+        self.assertFalse(collector.labels_match(requirements, {}))
+
+
+class ValidateRouterRequestTest(unittest.TestCase):
+    VALID = {
+        "route": "decoy",
+        "status": 200,
+        "source_ip": "203.0.113.7",
+        "path": "/",
+        "latency_ms": 1.5,
+        "sample_rate": 0.5,
+    }
+
+    def check(self, **changes):
+        return collector.validate_router_request({**self.VALID, **changes})
+
+    def test_valid_report(self):
+        self.assertIsNone(self.check())
+        self.assertIsNone(self.check(route="legit", latency_ms=None, sample_rate=1))
+
+    def test_route(self):
+        self.assertIn("route must be one of", self.check(route="real"))
+
+    def test_status(self):
+        for status in [None, "200", True, 99, 600, 200.0]:
+            with self.subTest(status=status):
+                self.assertIn("status must be", self.check(status=status))
+
+    def test_numbers(self):
+        for value in [-1, "5", True]:
+            with self.subTest(value=value):
+                self.assertIn("latency_ms must be", self.check(latency_ms=value))
+
+    def test_sample_rate(self):
+        for rate in [0, 1.5]:
+            with self.subTest(rate=rate):
+                self.assertIn("sample_rate must be", self.check(sample_rate=rate))
+
+    def test_strings(self):
+        self.assertIn("path must be a string", self.check(path=["/"]))
+        too_long = "x" * (collector.ROUTER_REPORT_MAX_CHARS + 1)
+        self.assertIn("exceeds", self.check(path=too_long))
+
+
+class ParseDurationTest(unittest.TestCase):
+    def test_units(self):
+        cases = {"30s": 30, "5m": 300, "1h": 3600, "45": 45, " 2 m ": 120, "0s": 0}
+        for value, seconds in cases.items():
+            with self.subTest(value=value):
+                self.assertEqual(collector.parse_duration(value), seconds)
+
+    def test_malformed(self):
+        for value in ["", None, "1d", "-5m", "1.5h", "m", "5mm"]:
+            with self.subTest(value=value):
+                self.assertIsNone(collector.parse_duration(value))
+
+
+class TimeseriesBucketsTest(unittest.TestCase):
+    # 30s past an hour, so 60s buckets end on the next minute
+    NOW = 1_700_000_000 - 1_700_000_000 % 3600 + 30
+
+    def test_buckets_are_aligned_and_end_with_the_current_one(self):
+        buckets = collector.timeseries_buckets([], self.NOW, 60, 3)
+        end = self.NOW - 30 + 60
+        self.assertEqual(
+            [b["timestamp"] for b in buckets],
+            [
+                collector.datetime.fromtimestamp(t, collector.timezone.utc).isoformat()
+                for t in (end - 180, end - 120, end - 60)
+            ],
+        )
+        self.assertEqual([b["count"] for b in buckets], [0, 0, 0])
+        self.assertEqual(buckets[0]["avg_latency"], None)
+
+    def test_samples_land_in_their_bucket_weighted(self):
+        samples = [
+            (self.NOW - 10, "decoy", 10.0, 1),
+            (self.NOW - 20, "legit", 30.0, 2),  # sampled at 0.5
+            (self.NOW - 70, "legit", None, 1),
+        ]
+        buckets = collector.timeseries_buckets(samples, self.NOW, 60, 3)
+        self.assertEqual([b["count"] for b in buckets], [0, 1, 3])
+        self.assertEqual(buckets[2]["by_route"], {"decoy": 1, "legit": 2})
+        self.assertEqual(buckets[1]["by_route"], {"legit": 1})
+        # (10*1 + 30*2) / 3
+        self.assertEqual(buckets[2]["avg_latency"], 23.3)
+        self.assertIsNone(buckets[1]["avg_latency"])
+
+    def test_samples_outside_the_window_are_ignored(self):
+        samples = [
+            (self.NOW - 1000, "decoy", None, 1),
+            (self.NOW + 1000, "decoy", None, 1),
+        ]
+        buckets = collector.timeseries_buckets(samples, self.NOW, 60, 3)
+        self.assertEqual(sum(b["count"] for b in buckets), 0)
+
+
+if __name__ == "__main__":
+    unittest.main()